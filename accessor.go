@@ -0,0 +1,83 @@
+package errors
+
+// 文件内容：
+//	1、Code() 返回错误链中第一个带错误码的错误的错误码
+//
+//	2、ExternalMessage()、InternalMessage() 返回外部（用户）可见的错误文本以及内部错误信息
+//
+//	3、type StackTracer interface
+//		fundamental、withStack、withCode 实现的接口，返回错误创建时的调用栈
+//
+// 这些函数都使用 As 查找错误链，因此可以穿过其他包的包装错误，
+// 例如 fmt.Errorf("...: %w", err)、*url.Error。
+
+// StackTracer 是记录了调用栈的错误，New、Errorf、WithStack、Wrap、WithCode、WrapC 等返回的错误都实现了该接口。
+// 可以使用 As 从错误链中取出：
+//
+//	var st errors.StackTracer
+//	if errors.As(err, &st) {
+//		fmt.Printf("%+v", st.StackTrace())
+//	}
+//
+// Decode、JSONError.UnmarshalJSON 重建的错误没有本地的调用栈，StackTrace() 为空，
+// 远程记录的调用栈见 JSONError.Record() 中每一层的 Frames。
+type StackTracer interface {
+	StackTrace() StackTrace
+}
+
+var (
+	_ StackTracer = (*fundamental)(nil)
+	_ StackTracer = (*withStack)(nil)
+	_ StackTracer = (*withCode)(nil)
+)
+
+// codedOf 返回错误链中第一个带错误码的错误
+func codedOf(err error) (*withCode, bool) {
+	var v *withCode
+	if err == nil || !As(err, &v) {
+		return nil, false
+	}
+
+	return v, true
+}
+
+// Code 返回错误链中第一个带错误码的错误的错误码，即使该错误码没有注册；
+// 错误链中没有带错误码的错误时 ok 为 false。
+func Code(err error) (code int, ok bool) {
+	v, ok := codedOf(err)
+	if !ok {
+		return 0, false
+	}
+
+	return v.code, true
+}
+
+// ExternalMessage 返回错误链中第一个带错误码的错误的外部（用户）可见的错误文本，与该错误使用 %s 格式化的结果相同。
+// 错误链中没有带错误码的错误时返回默认 Registry 的 unknown Coder 的外部错误文本，err 为 nil 时返回空。
+func ExternalMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	v, ok := codedOf(err)
+	if !ok {
+		return defaultRegistry.Unknown().String()
+	}
+
+	return v.text()
+}
+
+// InternalMessage 返回错误链中第一个带错误码的错误的内部错误信息，即创建该错误时 WithCode、WrapC 格式化的信息，
+// 不包括外部错误文本。错误链中没有带错误码的错误时返回 err.Error()，err 为 nil 时返回空。
+func InternalMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	v, ok := codedOf(err)
+	if !ok {
+		return err.Error()
+	}
+
+	return v.err.Error()
+}
@@ -0,0 +1,85 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAccessors(t *testing.T) {
+	MustRegister(NewCoder(260001, 404, "Order not found", ""))
+	defer Unregister(260001)
+
+	coded := WrapC(New("no rows"), 260001, "get order %d", 7)
+	unregistered := WithCode(260999, "unregistered")
+	unknown := defaultRegistry.Unknown().String()
+
+	tests := []struct {
+		name string
+		err  error
+
+		code     int
+		ok       bool
+		external string
+		internal string
+		stack    bool
+
+		// 重建的错误没有本地调用栈
+		rebuilt bool
+	}{
+		{"nil", nil, 0, false, "", "", false, false},
+		{"fundamental", New("boom"), 0, false, unknown, "boom", true, false},
+		{"std error", fmt.Errorf("boom"), 0, false, unknown, "boom", false, false},
+		{"with stack", WithStack(fmt.Errorf("boom")), 0, false, unknown, "boom", true, false},
+		{"with code", coded, 260001, true, "Order not found", "get order 7", true, false},
+		{"wrapped with code", Wrap(coded, "handle request"), 260001, true, "Order not found", "handle request", true, false},
+		{"std wrapped with code", fmt.Errorf("handle: %w", coded), 260001, true, "Order not found", "get order 7", true, false},
+		{"with fields", WithFields(coded, "order", 7), 260001, true, "Order not found", "get order 7", true, false},
+		{"unregistered code", unregistered, 260999, true, unknown, "unregistered", true, false},
+		{"decoded", Decode(Encode(Wrap(coded, "handle request"))), 260001, true, "Order not found", "handle request", true, true},
+		{"decoded std wrapped", Decode(Encode(fmt.Errorf("handle: %w", coded))), 260001, true, "Order not found", "get order 7", true, true},
+		{"decoded plain", Decode(Encode(New("boom"))), 0, false, unknown, "boom", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, ok := Code(tt.err)
+			if code != tt.code || ok != tt.ok {
+				t.Errorf("Code() = %d, %v, want %d, %v", code, ok, tt.code, tt.ok)
+			}
+
+			if got := ExternalMessage(tt.err); got != tt.external {
+				t.Errorf("ExternalMessage() = %q, want %q", got, tt.external)
+			}
+			if v, ok := codedOf(tt.err); ok {
+				// 与第一个带错误码的错误使用 %s 格式化的结果相同
+				if got := fmt.Sprintf("%s", v); got != tt.external {
+					t.Errorf("%%s = %q, ExternalMessage() = %q", got, tt.external)
+				}
+			}
+
+			if got := InternalMessage(tt.err); got != tt.internal {
+				t.Errorf("InternalMessage() = %q, want %q", got, tt.internal)
+			}
+
+			var st StackTracer
+			if got := tt.err != nil && As(tt.err, &st); got != tt.stack {
+				t.Fatalf("As(StackTracer) = %v, want %v", got, tt.stack)
+			}
+			if tt.stack && (len(st.StackTrace()) == 0) != tt.rebuilt {
+				t.Errorf("len(StackTrace()) = %d, rebuilt %v", len(st.StackTrace()), tt.rebuilt)
+			}
+		})
+	}
+}
+
+func TestStackTracerCaller(t *testing.T) {
+	var st StackTracer
+	if !As(fmt.Errorf("handle: %w", WithCode(260001, "order")), &st) {
+		t.Fatal("As(StackTracer) = false")
+	}
+
+	if got := fmt.Sprintf("%+v", st.StackTrace()[0]); !strings.Contains(got, "accessor_test.go") {
+		t.Errorf("StackTrace()[0] = %s, want the caller of WithCode", got)
+	}
+}
@@ -9,6 +9,8 @@ type MessageCountMap map[string]int
 // Aggregate 表示一个包含多个错误的对象，但不一定具有单一的语义。
 // 聚合可以与 `errors.Is()` 一起使用来检查特定错误类型的发生。
 // 不支持 Errors.As()，因为调用者可能关心与给定类型匹配的潜在多个特定错误。
+// NewAggregate、Join 返回的聚合错误实现了 Unwrap() []error，
+// 使用 Go 1.20 及以上版本编译时 As() 返回第一个匹配的错误。
 type Aggregate interface {
 	error
 	Errors() []error
@@ -37,6 +39,17 @@ func NewAggregate(errlist []error) Aggregate {
 	return aggregate(errs)
 }
 
+// Join 返回包装 errs 的错误，忽略其中的 nil，errs 全部为 nil 时返回 nil。
+// 与 Go 1.20 的 errors.Join 相同，返回的错误实现了 Unwrap() []error，
+// 但错误信息使用 Aggregate 的格式，例如 [err1, err2]，只有一个错误时与该错误相同。
+func Join(errs ...error) error {
+	if agg := NewAggregate(errs); agg != nil {
+		return agg
+	}
+
+	return nil
+}
+
 //=====================================================
 // 这个 helper 实现了 error 和 Errors 接口。
 // 保持私有可以防止人们产生 0 个错误的聚合，这不是错误，但确实满足错误接口。
@@ -72,19 +85,25 @@ func (agg aggregate) Errors() []error {
 
 // Error is part of the error interface.
 func (agg aggregate) Error() string {
+	registryOf(agg).renderedErr(agg)
+	return agg.text()
+}
+
+// text 返回 Error() 的结果，不计入渲染次数
+func (agg aggregate) text() string {
 	if len(agg) == 0 {
 		// This should never happen, really.
 		return ""
 	}
 
 	if len(agg) == 1 {
-		return agg[0].Error()
+		return errorText(agg[0])
 	}
 
 	seenerrs := NewString()
 	result := ""
 	agg.visit(func(err error) bool {
-		msg := err.Error()
+		msg := errorText(err)
 		if seenerrs.Has(msg) {
 			return false
 		}
@@ -109,6 +128,11 @@ func (agg aggregate) Is(target error) bool {
 	})
 }
 
+// Unwrap 返回聚合的所有错误，兼容 Go 1.20 的多错误包装。
+func (agg aggregate) Unwrap() []error {
+	return []error(agg)
+}
+
 //=====================================================
 // Matcher 用于匹配 error。 如果匹配，返回 true。
 type Matcher func(error) bool
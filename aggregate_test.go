@@ -0,0 +1,101 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestJoinNil(t *testing.T) {
+	tests := []struct {
+		name string
+		errs []error
+	}{
+		{"no errors", nil},
+		{"nil", []error{nil}},
+		{"all nil", []error{nil, nil}},
+	}
+
+	// 返回无类型的 nil，而不是值为 nil 的 Aggregate
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Join(tt.errs...); err != nil {
+				t.Errorf("Join(%v) = %#v, want nil", tt.errs, err)
+			}
+		})
+	}
+}
+
+func TestJoinUnwrap(t *testing.T) {
+	a, b := New("a"), New("b")
+
+	err := Join(a, nil, b)
+	u, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("Join() = %T, does not implement Unwrap() []error", err)
+	}
+	if got := u.Unwrap(); !reflect.DeepEqual(got, []error{a, b}) {
+		t.Errorf("Unwrap() = %v, want [a b] without nil", got)
+	}
+}
+
+func TestJoinIsAs(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(270001, 404, "Order not found", ""))
+
+	pathErr := &os.PathError{Op: "open", Path: "codes.json", Err: os.ErrNotExist}
+	coded := r.WithCode(270001, "order 7")
+	err := Join(Join(New("a"), fmt.Errorf("load: %w", pathErr)), Join(io.EOF, coded))
+
+	for _, target := range []error{io.EOF, os.ErrNotExist, coded} {
+		if !Is(err, target) {
+			t.Errorf("Is(%v, %v) = false", err, target)
+		}
+		if !errors.Is(err, target) {
+			t.Errorf("errors.Is(%v, %v) = false", err, target)
+		}
+	}
+	if Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Is(%v, io.ErrUnexpectedEOF) = true", err)
+	}
+
+	var pe *os.PathError
+	if !As(err, &pe) || pe != pathErr {
+		t.Errorf("As(*os.PathError) = %v", pe)
+	}
+
+	var st StackTracer
+	if !As(err, &st) {
+		t.Error("As(StackTracer) = false")
+	}
+
+	if !r.IsCode(err, 270001) {
+		t.Errorf("IsCode(%v, 270001) = false", err)
+	}
+	if code, ok := Code(err); !ok || code != 270001 {
+		t.Errorf("Code() = %d, %v", code, ok)
+	}
+}
+
+func TestJoinFormatNested(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(270001, 404, "Order not found", ""))
+
+	a := New("a")
+	err := Join(Join(a, r.WithCode(270001, "order 7")), fmt.Errorf("c: %w", New("inner")), Join(a))
+
+	// 嵌套的聚合错误被展开，相同的错误信息只输出一次
+	want := "[a, Order not found, c: inner]"
+	for _, verb := range []string{"%s", "%v", "%+v"} {
+		if got := fmt.Sprintf(verb, err); got != want {
+			t.Errorf("%s = %q, want %q", verb, got, want)
+		}
+	}
+
+	if got := fmt.Sprintf("%+v", Join(Join(a))); got != "a" {
+		t.Errorf("%%+v of a nested single error = %q, want %q", got, "a")
+	}
+}
@@ -0,0 +1,216 @@
+package errors
+
+import (
+	"log"
+)
+
+// 文件内容：
+//	1、别名：Registry.RegisterAlias()、Registry.MustRegisterAlias()、Registry.Resolve()
+//		错误码重新编号后，将旧错误码注册为新错误码的别名，
+//		ParseCoder、Lookup、IsCode 会将旧错误码解析为新错误码，
+//		因此 IsCode(err, 旧错误码) 与 IsCode(err, 新错误码) 的结果相同。
+//
+//	2、废弃：Registry.Deprecate()、Registry.MustDeprecate()、Registry.Deprecation()
+//		废弃的错误码仍然可以使用，可以指定替代它的错误码。别名也视为废弃。
+//		Registry 是废弃标记唯一的来源：Deprecation()、废弃警告、生成的文档以及 %#-v、%#+v 的 JSON 输出都读取它。
+//
+//	3、废弃警告
+//		使用 WithCode、WrapC 创建废弃错误码的错误时输出警告，每个错误码只输出一次。
+//		默认使用 log.Printf 输出，可以通过 Registry.SetLogf() 修改。
+//
+// 错误码目录（CatalogEntry）中的 Deprecated、Replacement、Aliases 字段对应废弃标记和别名，
+// 生成的文档会标记废弃的错误码，旧错误码的锚点指向新错误码。
+
+// copyIntMap 复制 m，m 为 nil 时返回空 map
+func copyIntMap(m map[int]int) map[int]int {
+	next := make(map[int]int, len(m))
+	for k, v := range m {
+		next[k] = v
+	}
+
+	return next
+}
+
+// resolve 返回别名 code 对应的新错误码，code 不是别名时原样返回
+func (s *registryState) resolve(code int) int {
+	if target, ok := s.aliases[code]; ok {
+		return target
+	}
+
+	return code
+}
+
+// registered 报告 code 是否注册为错误码（不包括别名和 unknown Coder）
+func (s *registryState) registered(code int) bool {
+	_, ok := s.codes[code]
+	return ok && code != s.unknown.Code()
+}
+
+// addAlias 将 old 注册为 code 的别名，code 本身是别名时使用它指向的新错误码，site 为注册的位置
+func (s *registryState) addAlias(old, code int, site Site) error {
+	if old == 0 || old == s.unknown.Code() {
+		return Errorf("code `%d` is reserved by registry as unknownCode error code", old)
+	}
+
+	if err := s.checkExist(old, site); err != nil {
+		return err
+	}
+
+	target := s.resolve(code)
+	if !s.registered(target) {
+		return Errorf("alias: code %d is not registered", code)
+	}
+	s.aliases[old] = target
+	s.sites[old] = site
+
+	return nil
+}
+
+// deprecate 将 code 标记为废弃，replacement 为 0 表示没有替代的错误码
+func (s *registryState) deprecate(code, replacement int) error {
+	if !s.registered(code) {
+		return Errorf("deprecate: code %d is not registered", code)
+	}
+
+	if replacement != 0 {
+		replacement = s.resolve(replacement)
+		if replacement == code {
+			return Errorf("deprecate: code %d cannot replace itself", code)
+		}
+		if !s.registered(replacement) {
+			return Errorf("deprecate: replacement code %d of %d is not registered", replacement, code)
+		}
+	}
+	s.deprecated[code] = replacement
+
+	return nil
+}
+
+// ===================================================================
+// RegisterAlias 将 old 注册为 code 的别名，code 必须已经注册，old 不能已经注册为错误码或别名。
+// 别名也被视为废弃的错误码，替代它的错误码为 code。
+func (r *Registry) RegisterAlias(old, code int) error {
+	site := callerSite()
+	return r.update(func(next *registryState) error {
+		return next.addAlias(old, code, site)
+	})
+}
+
+// MustRegisterAlias 与 RegisterAlias 相同，失败时将会引发 panic。
+func (r *Registry) MustRegisterAlias(old, code int) {
+	site := callerSite()
+	r.mustUpdate(func(next *registryState) error {
+		return next.addAlias(old, code, site)
+	})
+}
+
+// Resolve 返回别名 code 对应的新错误码，code 不是别名时原样返回。
+func (r *Registry) Resolve(code int) int {
+	return r.load().resolve(code)
+}
+
+// Deprecate 将已注册的 code 标记为废弃，replacement 为替代它的错误码，0 表示没有替代的错误码。
+func (r *Registry) Deprecate(code, replacement int) error {
+	return r.update(func(next *registryState) error {
+		return next.deprecate(code, replacement)
+	})
+}
+
+// MustDeprecate 与 Deprecate 相同，失败时将会引发 panic。
+func (r *Registry) MustDeprecate(code, replacement int) {
+	r.mustUpdate(func(next *registryState) error {
+		return next.deprecate(code, replacement)
+	})
+}
+
+// Deprecation 报告 code 是否已经废弃，replacement 为替代它的错误码，0 表示没有替代的错误码。
+func (r *Registry) Deprecation(code int) (replacement int, deprecated bool) {
+	return r.load().deprecation(code)
+}
+
+func (s *registryState) deprecation(code int) (int, bool) {
+	if target, ok := s.aliases[code]; ok {
+		return target, true
+	}
+
+	if replacement, ok := s.deprecated[code]; ok {
+		return replacement, true
+	}
+
+	return 0, false
+}
+
+// deprecationFields 将 code 的废弃标记添加到 JSON 输出的元数据 meta 中，meta 为 nil 时按需创建
+func (s *registryState) deprecationFields(meta map[string]interface{}, code int) map[string]interface{} {
+	replacement, ok := s.deprecation(code)
+	if !ok {
+		return meta
+	}
+
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	meta["deprecated"] = true
+	if replacement != 0 {
+		meta["replacement"] = replacement
+	}
+
+	return meta
+}
+
+// SetLogf 设置输出废弃警告的函数，logf 为 nil 时使用 log.Printf。冻结之后仍然可以调用。
+func (r *Registry) SetLogf(logf func(format string, args ...interface{})) {
+	_ = r.apply(false, func(next *registryState) error {
+		next.logf = logf
+		return nil
+	})
+}
+
+// hasDeprecations 报告 s 中是否有废弃的错误码（包括别名）
+func (s *registryState) hasDeprecations() bool {
+	return len(s.aliases) > 0 || len(s.deprecated) > 0
+}
+
+// warnDeprecated 在第一次使用 state 中废弃的错误码 code 时输出警告
+func (r *Registry) warnDeprecated(state *registryState, code int) {
+	replacement, ok := state.deprecation(code)
+	if !ok {
+		return
+	}
+
+	if _, warned := r.warned.LoadOrStore(code, struct{}{}); warned {
+		return
+	}
+
+	logf := state.logf
+	if logf == nil {
+		logf = log.Printf
+	}
+
+	if replacement != 0 {
+		logf("errors: code %d is deprecated, use %d instead", code, replacement)
+		return
+	}
+	logf("errors: code %d is deprecated", code)
+}
+
+// ===================================================================
+// RegisterAlias 将 old 注册为默认 Registry 中 code 的别名。
+func RegisterAlias(old, code int) error {
+	return defaultRegistry.RegisterAlias(old, code)
+}
+
+// MustRegisterAlias 将 old 注册为默认 Registry 中 code 的别名，失败时将会引发 panic。
+func MustRegisterAlias(old, code int) {
+	defaultRegistry.MustRegisterAlias(old, code)
+}
+
+// Deprecate 将默认 Registry 中的 code 标记为废弃。
+func Deprecate(code, replacement int) error {
+	return defaultRegistry.Deprecate(code, replacement)
+}
+
+// MustDeprecate 将默认 Registry 中的 code 标记为废弃，失败时将会引发 panic。
+func MustDeprecate(code, replacement int) {
+	defaultRegistry.MustDeprecate(code, replacement)
+}
@@ -0,0 +1,40 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDeprecationFromRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(RichCoder{C: 130001, HTTP: 400, Ext: "Invalid name", Team: "identity"})
+	r.MustRegister(NewCoder(130002, 400, "Invalid user name", ""))
+	r.MustRegister(NewCoder(130003, 404, "User not found", ""))
+	r.MustDeprecate(130001, 130002)
+
+	var warnings []string
+	r.SetLogf(func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	})
+
+	err := r.WithCode(130001, "name")
+	if len(warnings) != 1 {
+		t.Errorf("warnings = %q, want one deprecation warning", warnings)
+	}
+
+	if replacement, ok := r.Deprecation(130001); !ok || replacement != 130002 {
+		t.Errorf("Deprecation(130001) = %d, %v", replacement, ok)
+	}
+
+	got := fmt.Sprintf("%#-v", err)
+	for _, want := range []string{`"deprecated":true`, `"replacement":130002`, `"owner":"identity"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%%#-v = %s, missing %s", got, want)
+		}
+	}
+
+	if got := fmt.Sprintf("%#-v", r.WithCode(130003, "user")); strings.Contains(got, "deprecated") {
+		t.Errorf("%%#-v of a current code = %s", got)
+	}
+}
@@ -0,0 +1,361 @@
+package errors
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 文件内容：
+//	1、type CatalogEntry struct
+//		错误码目录中的一条定义：code、HTTP 状态码、外部错误文本、reference 文档。
+//
+//	2、目录解析：ParseCatalogJSON()、ParseCatalogText()
+//
+//	3、目录加载：Registry.LoadCatalog()、Registry.LoadCatalogFile()
+//		包级别 LoadCatalog()、LoadCatalogFile() 加载到默认 Registry。
+//
+//	4、目录导出：Registry.Catalog()，可用于生成文档（见 docgen 包）或者重新加载（见 reload.go）
+//
+// 目录格式：
+//	JSON 格式，一个 CatalogEntry 数组：
+//		[
+//		  {"code": 110001, "http": 404, "message": "User not found", "reference": "https://..."},
+//		  {"code": 110002, "http": 400, "message": "Invalid name", "deprecated": true, "replacement": 110003},
+//		  {"code": 110003, "http": 400, "message": "Invalid user name", "aliases": [100003]}
+//		]
+//
+//	文本格式，每行一条定义，字段之间使用 | 分隔，reference 可省略，不支持废弃标记和别名，
+//	空行以及 # 开头的行会被忽略：
+//		# code   | http | message        | reference
+//		110001   | 404  | User not found | https://...
+//
+// 目录加载是原子的：只要有一条定义不合法（code 为 0、重复的 code、格式错误），
+// 就不会注册任何一条定义，返回的错误包含所有问题。
+
+// CatalogFormat 表示错误码目录的格式。
+type CatalogFormat int
+
+const (
+	// CatalogJSON JSON 格式的目录
+	CatalogJSON CatalogFormat = iota
+	// CatalogText 基于行的文本格式目录
+	CatalogText
+)
+
+// CatalogEntry 是错误码目录中的一条定义。
+type CatalogEntry struct {
+	// Name 错误码的标识符，例如 Go 常量名，仅用于生成文档
+	Name string `json:"name,omitempty"`
+
+	// Code 错误码
+	Code int `json:"code"`
+
+	// HTTP 该错误码对应的 HTTP 状态码
+	HTTP int `json:"http"`
+
+	// Message 外部（用户）可见的错误文本
+	Message string `json:"message"`
+
+	// Reference 错误相关的 reference 文档
+	Reference string `json:"reference,omitempty"`
+
+	// Deprecated 该错误码是否已经废弃，Replacement 为替代它的错误码
+	Deprecated  bool `json:"deprecated,omitempty"`
+	Replacement int  `json:"replacement,omitempty"`
+
+	// Aliases 该错误码的别名（重新编号之前的旧错误码）
+	Aliases []int `json:"aliases,omitempty"`
+
+	// Site 错误码注册的位置，仅用于 Registry.Catalog() 的输出，注册目录时会被忽略
+	Site string `json:"site,omitempty"`
+
+	// pos 该定义在目录中的位置，用于错误提示
+	pos string
+}
+
+// Coder 将目录定义转换为 Coder。
+func (e CatalogEntry) Coder() Coder {
+	return defaultCoder{
+		C:    e.Code,
+		HTTP: e.HTTP,
+		Ext:  e.Message,
+		Ref:  e.Reference,
+	}
+}
+
+// where 返回该定义在目录中的位置描述。
+func (e CatalogEntry) where() string {
+	if e.pos != "" {
+		return e.pos
+	}
+	return "code " + strconv.Itoa(e.Code)
+}
+
+// source 返回该定义在目录中的位置，path 为目录文件的路径，可以为空
+func (e CatalogEntry) source(path string) string {
+	if path == "" {
+		return "catalog: " + e.where()
+	}
+	return path + ": " + e.where()
+}
+
+// validate 校验单条定义。
+func (e CatalogEntry) validate() error {
+	if e.Code == 0 {
+		return Errorf("catalog: %s: code `0` is reserved as unknownCode error code", e.where())
+	}
+
+	if e.HTTP < 100 || e.HTTP > 599 {
+		return Errorf("catalog: %s: invalid http status %d", e.where(), e.HTTP)
+	}
+
+	if strings.TrimSpace(e.Message) == "" {
+		return Errorf("catalog: %s: empty message", e.where())
+	}
+
+	return nil
+}
+
+// ===================================================================
+// ParseCatalogJSON 解析 JSON 格式的错误码目录。
+func ParseCatalogJSON(data []byte) ([]CatalogEntry, error) {
+	var entries []CatalogEntry
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&entries); err != nil {
+		return nil, Wrapf(err, "catalog: malformed json: %v", err)
+	}
+
+	for i := range entries {
+		entries[i].pos = "entry #" + strconv.Itoa(i)
+	}
+
+	return entries, nil
+}
+
+// ParseCatalogText 解析基于行的文本格式错误码目录。
+func ParseCatalogText(data []byte) ([]CatalogEntry, error) {
+	var (
+		entries []CatalogEntry
+		errs    []error
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "|")
+		if len(fields) < 3 || len(fields) > 4 {
+			errs = append(errs, Errorf("catalog: line %d: expect 3 or 4 fields separated by `|`, got %d", n, len(fields)))
+			continue
+		}
+
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		code, err := strconv.Atoi(fields[0])
+		if err != nil {
+			errs = append(errs, Errorf("catalog: line %d: invalid code %q", n, fields[0]))
+			continue
+		}
+
+		status, err := strconv.Atoi(fields[1])
+		if err != nil {
+			errs = append(errs, Errorf("catalog: line %d: invalid http status %q", n, fields[1]))
+			continue
+		}
+
+		entry := CatalogEntry{
+			Code:    code,
+			HTTP:    status,
+			Message: fields[2],
+			pos:     "line " + strconv.Itoa(n),
+		}
+		if len(fields) == 4 {
+			entry.Reference = fields[3]
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, Wrapf(err, "catalog: read failed: %v", err)
+	}
+
+	if agg := NewAggregate(errs); agg != nil {
+		return nil, agg
+	}
+
+	return entries, nil
+}
+
+// ParseCatalog 按照 format 解析错误码目录。
+func ParseCatalog(data []byte, format CatalogFormat) ([]CatalogEntry, error) {
+	if format == CatalogText {
+		return ParseCatalogText(data)
+	}
+
+	return ParseCatalogJSON(data)
+}
+
+// ===================================================================
+// LoadCatalog 从 rd 读取错误码目录，并将其中的定义注册到 r。
+func (r *Registry) LoadCatalog(rd io.Reader, format CatalogFormat) error {
+	data, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return Wrapf(err, "catalog: read failed: %v", err)
+	}
+
+	entries, err := ParseCatalog(data, format)
+	if err != nil {
+		return err
+	}
+
+	return r.RegisterCatalog(entries)
+}
+
+// LoadCatalogFile 读取 path 指定的错误码目录文件，并将其中的定义注册到 r。
+// 扩展名为 .json 的文件按照 JSON 格式解析，其他文件按照文本格式解析。
+func (r *Registry) LoadCatalogFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Wrapf(err, "catalog: read %s failed: %v", path, err)
+	}
+
+	entries, err := ParseCatalog(data, catalogFormatOf(path))
+	if err != nil {
+		return WithMessagef(err, "%s: %v", path, err)
+	}
+
+	if err := r.registerCatalog(entries, path); err != nil {
+		return WithMessagef(err, "%s: %v", path, err)
+	}
+
+	return nil
+}
+
+// RegisterCatalog 校验目录中的所有定义，全部合法时才将它们注册到 r，
+// 同时注册定义中的别名和废弃标记。
+// 与 MustRegister 相同，已经注册过的 code 会被视为重复定义。
+func (r *Registry) RegisterCatalog(entries []CatalogEntry) error {
+	return r.registerCatalog(entries, "")
+}
+
+// registerCatalog 实现 RegisterCatalog，path 为目录文件的路径，用于记录错误码注册的位置
+func (r *Registry) registerCatalog(entries []CatalogEntry, path string) error {
+	var errs []error
+
+	caller := callerSite()
+	seen := map[int]CatalogEntry{}
+	coders := make([]Coder, 0, len(entries))
+	sites := make([]Site, 0, len(entries))
+	for _, e := range entries {
+		if err := e.validate(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if prev, ok := seen[e.Code]; ok {
+			errs = append(errs, Errorf("catalog: %s: duplicate code %d, first defined at %s", e.where(), e.Code, prev.where()))
+			continue
+		}
+		seen[e.Code] = e
+
+		coders = append(coders, e.Coder())
+		sites = append(sites, Site{Frame: caller.Frame, Source: e.source(path)})
+	}
+
+	if agg := NewAggregate(errs); agg != nil {
+		return agg
+	}
+
+	return r.update(func(next *registryState) error {
+		if err := next.registerAll(coders, sites); err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			for _, old := range e.Aliases {
+				site := Site{Frame: caller.Frame, Source: e.source(path)}
+				if err := next.addAlias(old, e.Code, site); err != nil {
+					errs = append(errs, Errorf("catalog: %s: %s", e.where(), err.Error()))
+				}
+			}
+		}
+
+		for _, e := range entries {
+			if !e.Deprecated && e.Replacement == 0 {
+				continue
+			}
+
+			if err := next.deprecate(e.Code, e.Replacement); err != nil {
+				errs = append(errs, Errorf("catalog: %s: %s", e.where(), err.Error()))
+			}
+		}
+
+		return NewAggregate(errs)
+	})
+}
+
+// Catalog 返回 r 中所有已注册错误码的目录，按照错误码排序，不包含 unknown Coder。
+// 别名不会单独列出，而是记录在新错误码的 Aliases 中；Site 为错误码注册的位置。
+func (r *Registry) Catalog() []CatalogEntry {
+	state := r.load()
+	coders := r.coders()
+
+	aliases := map[int][]int{}
+	for old, code := range state.aliases {
+		aliases[code] = append(aliases[code], old)
+	}
+
+	entries := make([]CatalogEntry, 0, len(coders))
+	for _, coder := range coders {
+		e := CatalogEntry{
+			Code:      coder.Code(),
+			HTTP:      coder.HTTPStatus(),
+			Message:   coder.String(),
+			Reference: coder.Reference(),
+			Aliases:   aliases[coder.Code()],
+		}
+		e.Replacement, e.Deprecated = state.deprecation(coder.Code())
+		if site, ok := state.sites[coder.Code()]; ok {
+			e.Site = site.String()
+		}
+		sort.Ints(e.Aliases)
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// catalogFormatOf 根据文件扩展名判断目录格式。
+func catalogFormatOf(path string) CatalogFormat {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return CatalogJSON
+	}
+
+	return CatalogText
+}
+
+// LoadCatalog 从 rd 读取错误码目录，并将其中的定义注册到默认 Registry。
+func LoadCatalog(rd io.Reader, format CatalogFormat) error {
+	return defaultRegistry.LoadCatalog(rd, format)
+}
+
+// LoadCatalogFile 读取错误码目录文件，并将其中的定义注册到默认 Registry。
+func LoadCatalogFile(path string) error {
+	return defaultRegistry.LoadCatalogFile(path)
+}
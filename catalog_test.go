@@ -0,0 +1,196 @@
+package errors
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCatalogText(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []CatalogEntry
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			data: "# code | http | message | reference\n\n110001 | 404 | User not found | https://example.com\n110002|400|Invalid name\n",
+			want: []CatalogEntry{
+				{Code: 110001, HTTP: 404, Message: "User not found", Reference: "https://example.com", pos: "line 3"},
+				{Code: 110002, HTTP: 400, Message: "Invalid name", pos: "line 4"},
+			},
+		},
+		{
+			name:    "too few fields",
+			data:    "110001 | 404\n",
+			wantErr: []string{"line 1: expect 3 or 4 fields separated by `|`, got 2"},
+		},
+		{
+			name:    "too many fields",
+			data:    "110001 | 404 | a | b | c\n",
+			wantErr: []string{"line 1: expect 3 or 4 fields separated by `|`, got 5"},
+		},
+		{
+			name:    "invalid code and status",
+			data:    "abc | 404 | a\n110001 | OK | a\n",
+			wantErr: []string{`line 1: invalid code "abc"`, `line 2: invalid http status "OK"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCatalogText([]byte(tt.data))
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatalf("ParseCatalogText() = %v, want an error", got)
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("error %q does not contain %q", err, want)
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseCatalogText() = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseCatalogText() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Code != tt.want[i].Code || got[i].HTTP != tt.want[i].HTTP || got[i].Message != tt.want[i].Message ||
+					got[i].Reference != tt.want[i].Reference || got[i].pos != tt.want[i].pos {
+					t.Errorf("entry %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCatalogJSON(t *testing.T) {
+	entries, err := ParseCatalogJSON([]byte(`[{"code": 110001, "http": 404, "message": "User not found", "aliases": [100001]}]`))
+	if err != nil {
+		t.Fatalf("ParseCatalogJSON() = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Code != 110001 || entries[0].Aliases[0] != 100001 || entries[0].pos != "entry #0" {
+		t.Errorf("ParseCatalogJSON() = %+v", entries)
+	}
+
+	for _, data := range []string{`[{"code": 110001`, `{"code": 110001}`, `[{"code": 110001, "unknown": 1}]`} {
+		if _, err := ParseCatalogJSON([]byte(data)); err == nil || !strings.Contains(err.Error(), "catalog: malformed json") {
+			t.Errorf("ParseCatalogJSON(%s) = %v, want a malformed json error", data, err)
+		}
+	}
+}
+
+func TestLoadCatalogRejectsInvalidEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr []string
+	}{
+		{
+			name:    "duplicate code in one file",
+			data:    "110001 | 404 | A\n110002 | 400 | B\n110001 | 409 | C\n",
+			wantErr: []string{"line 3: duplicate code 110001, first defined at line 1"},
+		},
+		{
+			name:    "zero code",
+			data:    "0 | 500 | Zero\n",
+			wantErr: []string{"line 1: code `0` is reserved"},
+		},
+		{
+			name:    "invalid status and empty message",
+			data:    "110001 | 999 | A\n110002 | 400 |  \n",
+			wantErr: []string{"line 1: invalid http status 999", "line 2: empty message"},
+		},
+		{
+			name:    "already registered",
+			data:    "110002 | 400 | B\n100001 | 404 | Registered\n",
+			wantErr: []string{"code: 100001 already exist"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRegistry(1)
+
+			err := r.LoadCatalog(strings.NewReader(tt.data), CatalogText)
+			if err == nil {
+				t.Fatal("LoadCatalog() succeeded")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+
+			// 加载失败时不注册任何一条定义
+			if got := len(r.Codes()); got != 1 {
+				t.Errorf("len(Codes()) = %d after a failed load, want 1", got)
+			}
+		})
+	}
+}
+
+func TestLoadCatalogFailedAliasLeavesRegistryUntouched(t *testing.T) {
+	r := newTestRegistry(1)
+
+	// 别名与已注册的错误码冲突，新的错误码也不能注册
+	err := r.LoadCatalog(strings.NewReader(`[{"code": 110001, "http": 404, "message": "A", "aliases": [100001]}]`), CatalogJSON)
+	if err == nil {
+		t.Fatal("LoadCatalog() succeeded with a conflicting alias")
+	}
+
+	if _, ok := r.Lookup(110001); ok {
+		t.Error("failed load registered 110001")
+	}
+}
+
+func TestLoadCatalogFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "codes.json")
+	data := `[
+  {"code": 110001, "http": 404, "message": "User not found"},
+  {"code": 110002, "http": 400, "message": "Invalid name", "deprecated": true, "replacement": 110003},
+  {"code": 110003, "http": 400, "message": "Invalid user name", "aliases": [100003]}
+]`
+	if err := ioutil.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry()
+	if err := r.LoadCatalogFile(path); err != nil {
+		t.Fatalf("LoadCatalogFile() = %v", err)
+	}
+
+	if coder, ok := r.Lookup(100003); !ok || coder.Code() != 110003 {
+		t.Errorf("Lookup(alias 100003) = %v, %v", coder, ok)
+	}
+	if replacement, ok := r.Deprecation(110002); !ok || replacement != 110003 {
+		t.Errorf("Deprecation(110002) = %d, %v", replacement, ok)
+	}
+	if site, _ := r.Site(110001); !strings.Contains(site.String(), "codes.json: entry #0") {
+		t.Errorf("Site(110001) = %s", site)
+	}
+
+	// 再次加载同一个文件，所有的错误码都已经注册
+	err := r.LoadCatalogFile(path)
+	if err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("LoadCatalogFile() again = %v, want an error mentioning %s", err, path)
+	}
+
+	bad := filepath.Join(dir, "bad.txt")
+	if err := ioutil.WriteFile(bad, []byte("110009 | 404\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.LoadCatalogFile(bad); err == nil || !strings.Contains(err.Error(), "bad.txt") || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("LoadCatalogFile(bad.txt) = %v", err)
+	}
+	if _, ok := r.Lookup(110009); ok {
+		t.Error("malformed file registered 110009")
+	}
+}
@@ -0,0 +1,274 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// 文件内容：
+//	1、DecodeResponse() 将 HTTP 错误响应还原为带错误码的错误
+//
+//	2、type ResponseError struct
+//		记录返回错误的 HTTP 请求：方法、URL、状态码、请求 ID 以及截断后的响应体
+//
+//	3、type Transport struct
+//		http.RoundTripper，在 RoundTrip 中还原错误响应，并将还原的错误附加到响应上
+//
+//	4、CheckResponse() 检查 http.Client.Do 的结果，将错误响应转换为 DecodeResponse 返回的错误
+//	   type Client struct：调用 CheckResponse 的 HTTP 客户端
+//
+// http.RoundTripper 收到响应时必须返回 nil 错误（见 http.RoundTripper 的文档），
+// http.Client 会忽略同时返回的响应和错误，因此 Transport 对错误响应仍然返回 resp, nil，
+// 响应体被重新缓冲，可以完整读取；还原的错误由 DecodeResponse、CheckResponse 或 Client 取出。
+//
+// 支持 ErrorHandler 写入的响应体以及 application/problem+json 文档（见 Problem），
+// 还原的错误携带远程服务返回的错误码、外部错误文本、HTTP 状态码以及 reference 文档，
+// 即使该错误码没有在本地注册，IsCode、ParseCoder、%s 格式化也能得到远程的错误码和错误文本。
+
+const (
+	// maxDecodeBody 解析错误响应时最多读取的字节数
+	maxDecodeBody = 1 << 20
+
+	// maxRecordedBody ResponseError 中最多记录的响应体字节数
+	maxRecordedBody = 512
+)
+
+// ResponseError 记录返回错误的 HTTP 请求，是 DecodeResponse 返回的错误的 cause。
+type ResponseError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	RequestID  string
+
+	// Body 是截断后的响应体
+	Body string
+}
+
+func (e *ResponseError) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+
+	return msg
+}
+
+// responseBody 是 ErrorHandler 写入的响应体以及 Problem 文档中用到的成员
+type responseBody struct {
+	Code      *int   `json:"code"`
+	Message   string `json:"message"`
+	Reference string `json:"reference"`
+	RequestID string `json:"requestId"`
+
+	Title  string `json:"title"`
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+// ===================================================================
+// DecodeResponse 将状态码不是 2xx 的响应还原为错误，2xx 响应返回 nil。
+//
+// 响应体包含错误码时，返回由 r 渲染的 withCode 错误，其 Coder 使用远程服务返回的
+// 错误码、外部错误文本、reference 文档以及响应的 HTTP 状态码；
+// 否则返回不带错误码的错误。两种错误的 cause 都是 *ResponseError。
+//
+// DecodeResponse 会读取响应体，但不会关闭它，读取的内容仍然可以从 resp.Body 中读取。
+// resp.Body 是 Transport 返回的响应体时，直接返回 Transport 还原的错误，不再解析响应体。
+func (r *Registry) DecodeResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	if body, ok := resp.Body.(*decodedBody); ok {
+		return body.err
+	}
+
+	var data []byte
+	if resp.Body != nil {
+		var err error
+		data, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxDecodeBody))
+		resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), resp.Body), Closer: resp.Body}
+		if err != nil {
+			return Wrapf(err, "read response of %s failed: %v", requestString(resp), err)
+		}
+	}
+
+	re := &ResponseError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get(RequestIDHeader),
+		Body:       truncate(string(data), maxRecordedBody),
+	}
+	if req := resp.Request; req != nil {
+		re.Method = req.Method
+		re.URL = req.URL.String()
+	}
+
+	var body responseBody
+	if json.Unmarshal(data, &body) != nil || body.Code == nil {
+		return WithStack(re)
+	}
+
+	if body.RequestID != "" {
+		re.RequestID = body.RequestID
+	}
+
+	coder := defaultCoder{
+		C:    *body.Code,
+		HTTP: resp.StatusCode,
+		Ext:  firstNonEmpty(body.Message, body.Title),
+		Ref:  firstNonEmpty(body.Reference, body.Type),
+	}
+
+	return &withCode{
+		err:      fmt.Errorf("%s %s: %s", re.Method, re.URL, firstNonEmpty(body.Detail, coder.Ext)),
+		code:     coder.C,
+		cause:    re,
+		registry: r,
+		coder:    coder,
+		stack:    callers(),
+	}
+}
+
+// requestString 返回 resp 对应请求的方法和 URL
+func requestString(resp *http.Response) string {
+	if req := resp.Request; req != nil {
+		return req.Method + " " + req.URL.String()
+	}
+
+	return "request"
+}
+
+// truncate 将 s 截断为最多 n 个字节，不会截断 UTF-8 字符
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n] + "...(truncated)"
+}
+
+func utf8RuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// readCloser 组合 Reader 和 Closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// decodedBody 是 Transport 返回的错误响应的响应体，记录了还原的错误
+type decodedBody struct {
+	io.ReadCloser
+	err error
+}
+
+// ===================================================================
+// Transport 是还原错误响应的 http.RoundTripper，零值可以直接使用，例如：
+//
+//	client := &http.Client{Transport: &errors.Transport{}}
+//	resp, err := errors.CheckResponse(client.Do(req))
+//
+// 与 http.RoundTripper 的约定相同，收到响应时 RoundTrip 总是返回 resp, nil：
+// 状态码不小于 400 的响应在 RoundTrip 中使用 DecodeResponse 还原为错误，
+// 响应体被重新缓冲，调用者仍然可以完整读取并且需要关闭它；
+// 还原的错误附加在响应上，由 DecodeResponse、CheckResponse 或 Client 取出。
+type Transport struct {
+	// Base 用于发送请求，为 nil 时使用 http.DefaultTransport
+	Base http.RoundTripper
+
+	// Registry 用于渲染还原的错误，为 nil 时使用默认 Registry
+	Registry *Registry
+}
+
+// RoundTrip 使用 Base 发送 req，并还原错误响应，见 Transport。
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+
+	r := t.Registry
+	if r == nil {
+		r = defaultRegistry
+	}
+
+	decodeErr := r.DecodeResponse(resp)
+	if resp.Body != nil {
+		resp.Body = &decodedBody{ReadCloser: resp.Body, err: decodeErr}
+	}
+
+	return resp, nil
+}
+
+// ===================================================================
+// CheckResponse 检查 http.Client.Do 的结果：err 不为 nil 时原样返回；
+// 状态码不小于 400 时关闭响应体，返回 nil 和 DecodeResponse 得到的错误；否则原样返回 resp。
+// 3xx 响应原样返回，以便调用者处理没有跟随的重定向。用法：
+//
+//	resp, err := r.CheckResponse(client.Do(req))
+func (r *Registry) CheckResponse(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+	defer resp.Body.Close()
+
+	return nil, r.DecodeResponse(resp)
+}
+
+// Client 是将错误响应转换为错误的 HTTP 客户端，零值可以直接使用。
+type Client struct {
+	// HTTPClient 用于发送请求，为 nil 时使用 http.DefaultClient
+	HTTPClient *http.Client
+
+	// Registry 用于渲染还原的错误，为 nil 时使用默认 Registry
+	Registry *Registry
+}
+
+// Do 发送 req，状态码不小于 400 的响应使用 CheckResponse 转换为错误。
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	r := c.Registry
+	if r == nil {
+		r = defaultRegistry
+	}
+
+	return r.CheckResponse(client.Do(req))
+}
+
+// DecodeResponse 使用默认 Registry 将错误响应还原为错误。
+func DecodeResponse(resp *http.Response) error {
+	return defaultRegistry.DecodeResponse(resp)
+}
+
+// CheckResponse 使用默认 Registry 检查 http.Client.Do 的结果。
+func CheckResponse(resp *http.Response, err error) (*http.Response, error) {
+	return defaultRegistry.CheckResponse(resp, err)
+}
@@ -0,0 +1,171 @@
+package errors
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// closeTracker 记录响应体是否已经关闭
+type closeTracker struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *closeTracker) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestClientDecodesErrorResponse(t *testing.T) {
+	remote := newHTTPTestRegistry()
+	h := &ErrorHandler{Registry: remote, Logf: func(string, ...interface{}) {}}
+	server := httptest.NewServer(h.Handle(func(w http.ResponseWriter, req *http.Request) error {
+		if req.URL.Path == "/ok" {
+			_, err := fmt.Fprint(w, "ok")
+			return err
+		}
+		return remote.WithCode(150001, "get user")
+	}))
+	defer server.Close()
+
+	// 本地没有注册 150001
+	local := NewRegistry()
+	c := &Client{Registry: local}
+
+	req, _ := http.NewRequest("GET", server.URL+"/users/alice", nil)
+	resp, err := c.Do(req)
+	if resp != nil || err == nil {
+		t.Fatalf("Do() = %v, %v, want a decoded error", resp, err)
+	}
+
+	if !local.IsCode(err, 150001) {
+		t.Errorf("IsCode(%v, 150001) = false", err)
+	}
+	if coder := local.ParseCoder(err); coder.HTTPStatus() != 404 || coder.String() != "User not found" {
+		t.Errorf("ParseCoder() = %d %q", coder.HTTPStatus(), coder.String())
+	}
+
+	var re *ResponseError
+	if !As(err, &re) || re.Method != "GET" || !strings.HasSuffix(re.URL, "/users/alice") || re.StatusCode != 404 {
+		t.Errorf("ResponseError = %+v", re)
+	}
+
+	req, _ = http.NewRequest("GET", server.URL+"/ok", nil)
+	resp, err = c.Do(req)
+	if err != nil {
+		t.Fatalf("Do(/ok) = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if body, _ := ioutil.ReadAll(resp.Body); string(body) != "ok" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	r := NewRegistry()
+
+	body := &closeTracker{Reader: strings.NewReader(`{"code": 150003, "message": "Conflict"}`)}
+	resp := &http.Response{StatusCode: 409, Header: http.Header{}, Body: body}
+	got, err := r.CheckResponse(resp, nil)
+	if got != nil || !r.IsCode(err, 150003) {
+		t.Errorf("CheckResponse(409) = %v, %v", got, err)
+	}
+	if !body.closed {
+		t.Error("CheckResponse did not close the body of an error response")
+	}
+
+	// 不带错误码的响应体还原为不带错误码的错误
+	body = &closeTracker{Reader: strings.NewReader("bad gateway")}
+	resp = &http.Response{StatusCode: 502, Header: http.Header{}, Body: body}
+	if _, err := r.CheckResponse(resp, nil); err == nil || !strings.Contains(err.Error(), "bad gateway") {
+		t.Errorf("CheckResponse(502) = %v", err)
+	}
+
+	for _, status := range []int{200, 204, 302} {
+		body = &closeTracker{Reader: strings.NewReader("")}
+		resp = &http.Response{StatusCode: status, Header: http.Header{}, Body: body}
+		if got, err := r.CheckResponse(resp, nil); got != resp || err != nil || body.closed {
+			t.Errorf("CheckResponse(%d) = %v, %v, closed %v", status, got, err, body.closed)
+		}
+	}
+
+	sendErr := New("connection refused")
+	if got, err := r.CheckResponse(nil, sendErr); got != nil || err != sendErr {
+		t.Errorf("CheckResponse(nil, err) = %v, %v", got, err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	remote := newHTTPTestRegistry()
+	h := &ErrorHandler{Registry: remote, Logf: func(string, ...interface{}) {}}
+	server := httptest.NewServer(h.Handle(func(w http.ResponseWriter, req *http.Request) error {
+		if req.URL.Path == "/ok" {
+			_, err := fmt.Fprint(w, "ok")
+			return err
+		}
+		return remote.WithCode(150001, "get user")
+	}))
+	defer server.Close()
+
+	local := NewRegistry()
+	clients := []struct {
+		name   string
+		client *http.Client
+	}{
+		{"transport", &http.Client{Transport: &Transport{Registry: local}}},
+		// 设置了 Timeout 的 http.Client 会包装响应体，DecodeResponse 重新解析缓冲的响应体
+		{"timeout", &http.Client{Transport: &Transport{Registry: local}, Timeout: time.Minute}},
+	}
+
+	for _, c := range clients {
+		t.Run(c.name, func(t *testing.T) {
+			// 与 http.RoundTripper 的约定相同，错误响应返回 resp, nil
+			resp, err := c.client.Get(server.URL + "/users/alice")
+			if err != nil {
+				t.Fatalf("Get() = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != 404 {
+				t.Errorf("status = %d, want 404", resp.StatusCode)
+			}
+
+			err = local.DecodeResponse(resp)
+			if !local.IsCode(err, 150001) {
+				t.Errorf("DecodeResponse() = %v, want code 150001", err)
+			}
+			var re *ResponseError
+			if !As(err, &re) || re.Method != "GET" || !strings.HasSuffix(re.URL, "/users/alice") {
+				t.Errorf("ResponseError = %+v", re)
+			}
+
+			// 响应体被重新缓冲，仍然可以完整读取
+			if body, _ := ioutil.ReadAll(resp.Body); !strings.Contains(string(body), `"code":150001`) {
+				t.Errorf("body = %q", body)
+			}
+
+			if _, err := local.CheckResponse(c.client.Get(server.URL + "/users/bob")); !local.IsCode(err, 150001) {
+				t.Errorf("CheckResponse() = %v, want code 150001", err)
+			}
+
+			resp, err = c.client.Get(server.URL + "/ok")
+			if err != nil {
+				t.Fatalf("Get(/ok) = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if err := local.DecodeResponse(resp); err != nil {
+				t.Errorf("DecodeResponse(/ok) = %v", err)
+			}
+			if body, _ := ioutil.ReadAll(resp.Body); string(body) != "ok" {
+				t.Errorf("body = %q", body)
+			}
+		})
+	}
+}
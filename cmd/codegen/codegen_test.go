@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// checkGolden 比较 got 与 testdata 中的 golden 文件，-update 时更新 golden 文件
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from %s, run go test -update to update it\ngot:\n%s", name, path, got)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	pkg, err := parsePackage(filepath.Join("testdata", "codes"), "int")
+	if err != nil {
+		t.Fatalf("parsePackage() = %v", err)
+	}
+
+	if err := pkg.validate(map[int]bool{400: true, 404: true, 500: true}); err != nil {
+		t.Fatalf("validate() = %v", err)
+	}
+
+	src := pkg.generate([]string{"-type=int", "-ref=https://example.com/errors.html"}, "https://example.com/errors.html")
+	checkGolden(t, "zz_generated.errcode.go", src)
+
+	// 所有错误码在一次调用中注册
+	if n := strings.Count(string(src), "errors.MustRegister("); n != 1 {
+		t.Errorf("generated %d MustRegister calls, want 1", n)
+	}
+
+	md, err := pkg.markdown("https://example.com/errors.html")
+	if err != nil {
+		t.Fatalf("markdown() = %v", err)
+	}
+	checkGolden(t, "error_code_generated.md", md)
+}
+
+func TestValidate(t *testing.T) {
+	pkg := &errPackage{name: "codes", codes: []errCode{
+		{name: "ErrA", code: 110001, http: 404, message: "A"},
+		{name: "ErrB", code: 110001, http: 400, message: "B"},
+		{name: "ErrC", code: 110002},
+		{name: "ErrD", code: 110003, http: 418, message: "D"},
+		{name: "ErrE", code: 0, http: 400, message: "E"},
+		{name: "ErrF", code: 110004, http: 400, message: "F", deprecated: true, replacement: "ErrMissing"},
+	}}
+
+	err := pkg.validate(map[int]bool{400: true, 404: true})
+	if err == nil {
+		t.Fatal("validate() succeeded")
+	}
+
+	for _, want := range []string{
+		"ErrB: duplicate code 110001, first defined by ErrA",
+		"ErrC: missing `<http status>: <message>` annotation",
+		"ErrD: http status 418 is not allowed",
+		"ErrE: code `0` is reserved",
+		"ErrF: replacement ErrMissing is not a different error code constant",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validate() error does not contain %q:\n%s", want, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/tiandh987/errors"
+	"github.com/tiandh987/errors/docgen"
+)
+
+// generate 生成注册错误码的 Go 源文件，args 为 codegen 的命令行参数，写入文件头的注释中。
+// 所有错误码在一次 errors.MustRegister 调用中注册，注册表的状态只复制一次。
+func (p *errPackage) generate(args []string, refBase string) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by \"codegen %s\"; DO NOT EDIT.\n\n", strings.Join(args, " "))
+	fmt.Fprintf(&buf, "package %s\n\n", p.name)
+	fmt.Fprintf(&buf, "import \"github.com/tiandh987/errors\"\n\n")
+	fmt.Fprintf(&buf, "func init() {\n")
+	fmt.Fprintf(&buf, "\terrors.MustRegister(\n")
+	for _, ec := range p.codes {
+		fmt.Fprintf(&buf, "\t\terrors.NewCoder(%s, %d, %q, %q),\n", ec.name, ec.http, ec.message, p.reference(refBase, ec.code))
+	}
+	fmt.Fprintf(&buf, "\t)\n")
+	for _, ec := range p.codes {
+		if !ec.deprecated {
+			continue
+		}
+
+		replacement := ec.replacement
+		if replacement == "" {
+			replacement = "0"
+		}
+		fmt.Fprintf(&buf, "\terrors.MustDeprecate(%s, %s)\n", ec.name, replacement)
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		// 不应该发生，返回未格式化的代码以便排查
+		return buf.Bytes()
+	}
+
+	return src
+}
+
+// catalog 将错误码常量转换为错误码目录
+func (p *errPackage) catalog(refBase string) []errors.CatalogEntry {
+	entries := make([]errors.CatalogEntry, 0, len(p.codes))
+	for _, ec := range p.codes {
+		var replacement int
+		if r, ok := p.lookup(ec.replacement); ok {
+			replacement = r.code
+		}
+
+		entries = append(entries, errors.CatalogEntry{
+			Name:        ec.name,
+			Code:        ec.code,
+			HTTP:        ec.http,
+			Message:     ec.message,
+			Reference:   p.reference(refBase, ec.code),
+			Deprecated:  ec.deprecated,
+			Replacement: replacement,
+		})
+	}
+
+	return entries
+}
+
+// reference 返回错误码在文档中的 URL，refBase 为空时返回空字符串
+func (p *errPackage) reference(refBase string, code int) string {
+	if refBase == "" {
+		return ""
+	}
+	return errors.ReferenceURL(refBase, code)
+}
+
+// markdown 生成错误码 Markdown 文档
+func (p *errPackage) markdown(refBase string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := docgen.WriteMarkdown(&buf, p.title(), p.catalog(refBase)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// html 生成错误码 HTML 文档
+func (p *errPackage) html(refBase string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := docgen.WriteHTML(&buf, p.title(), p.catalog(refBase)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// title 返回文档标题
+func (p *errPackage) title() string {
+	return p.name + " 错误码"
+}
@@ -0,0 +1,142 @@
+// codegen 根据带注释的 Go 常量生成错误码注册代码以及错误码文档。
+//
+// 错误码常量的注释约定为 `<HTTP 状态码>: <外部错误文本>`，
+// 注释可以写在常量上方，也可以写在同一行，例如：
+//
+//	const (
+//		// ErrUserNotFound - 404: User not found.
+//		ErrUserNotFound int = iota + 110001
+//
+//		ErrUserAlreadyExist // 400: User already exist.
+//	)
+//
+// 在包中添加 go generate 指令：
+//
+//	//go:generate codegen -type=int
+//
+// 会在包目录下生成：
+//
+//	zz_generated.errcode.go  通过一次 errors.MustRegister 调用注册所有错误码
+//	error_code_generated.md  错误码 Markdown 文档
+//
+// 注释中以 `Deprecated:` 开头的段落将错误码标记为废弃，可以指定替代它的常量，例如：
+//
+//	// ErrUserNameInvalid - 400: Invalid user name.
+//	//
+//	// Deprecated: use ErrUserNameTooLong.
+//	ErrUserNameInvalid
+//
+// 生成的代码会通过 errors.MustDeprecate 标记废弃的错误码，文档中也会标记出来。
+//
+// 指定 -html 时还会生成自包含的 HTML 文档；指定 -ref 时，
+// 每个错误码的 Reference() 会指向文档中该错误码的锚点。
+//
+// 出现重复的错误码、缺少注释或者 HTTP 状态码不在允许的集合中时，codegen 会报错退出。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	typeName = flag.String("type", "int", "error code constant type name")
+	output   = flag.String("output", "zz_generated.errcode.go", "output file name of generated registrations")
+	doc      = flag.String("doc", "error_code_generated.md", "output file name of generated markdown, empty to skip")
+	html     = flag.String("html", "", "output file name of generated html, empty to skip")
+	refBase  = flag.String("ref", "", "base url of the error code document, used to build Coder.Reference()")
+	allowed  = flag.String("allowed", "200,400,401,403,404,500", "comma-separated list of allowed http statuses")
+)
+
+// Usage 打印使用说明
+func Usage() {
+	fmt.Fprintf(os.Stderr, "Usage of codegen:\n")
+	fmt.Fprintf(os.Stderr, "\tcodegen [flags] [directory]\n")
+	fmt.Fprintf(os.Stderr, "For more information, see:\n")
+	fmt.Fprintf(os.Stderr, "\thttps://github.com/tiandh987/errors/tree/master/cmd/codegen\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("codegen: ")
+	flag.Usage = Usage
+	flag.Parse()
+
+	dir := "."
+	if args := flag.Args(); len(args) > 0 {
+		dir = args[0]
+	}
+
+	statuses, err := parseAllowed(*allowed)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := parsePackage(dir, *typeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := pkg.validate(statuses); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writeFile(filepath.Join(dir, *output), pkg.generate(os.Args[1:], *refBase)); err != nil {
+		log.Fatal(err)
+	}
+
+	if *doc != "" {
+		data, err := pkg.markdown(*refBase)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := writeFile(filepath.Join(dir, *doc), data); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *html != "" {
+		data, err := pkg.html(*refBase)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := writeFile(filepath.Join(dir, *html), data); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// parseAllowed 解析允许的 HTTP 状态码列表
+func parseAllowed(s string) (map[int]bool, error) {
+	statuses := map[int]bool{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		status, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid http status %q in -allowed", field)
+		}
+		statuses[status] = true
+	}
+
+	return statuses, nil
+}
+
+// writeFile 将 data 写入 path
+func writeFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing output: %s", err)
+	}
+
+	return nil
+}
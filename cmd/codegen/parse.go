@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// annotation 匹配错误码常量的注释，例如：
+//
+//	404: User not found.
+//	ErrUserNotFound - 404: User not found.
+var annotation = regexp.MustCompile(`^(?:\w+\s+-\s+)?(\d{3}):\s*(.+?)\s*$`)
+
+// deprecation 匹配废弃标记，例如：
+//
+//	Deprecated: use ErrUserNameTooLong.
+//	Deprecated.
+var deprecation = regexp.MustCompile(`^Deprecated(?::\s*(?:[Uu]se\s+(\w+))?.*|\.?)$`)
+
+// errCode 是一个错误码常量的定义
+type errCode struct {
+	name    string // 常量名
+	code    int    // 错误码
+	http    int    // HTTP 状态码，0 表示缺少注释
+	message string // 外部错误文本
+	pos     token.Position
+
+	deprecated  bool   // 是否已经废弃
+	replacement string // 替代该错误码的常量名，可以为空
+}
+
+// errPackage 包含一个包中所有的错误码常量
+type errPackage struct {
+	name  string
+	codes []errCode
+}
+
+// parsePackage 解析 dir 目录下的 Go 文件，提取类型为 typeName 的常量
+func parsePackage(dir, typeName string) (*errPackage, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && !strings.HasPrefix(fi.Name(), "zz_generated.")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expect exactly one package in %s, found %d", dir, len(pkgs))
+	}
+
+	var (
+		astPkg *ast.Package
+		files  []*ast.File
+	)
+	for _, p := range pkgs {
+		astPkg = p
+	}
+	for _, f := range astPkg.Files {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return fset.File(files[i].Pos()).Name() < fset.File(files[j].Pos()).Name()
+	})
+
+	// 使用 go/types 计算常量值，以支持 iota 等常量表达式；
+	// 导入失败等与常量无关的错误会被忽略。
+	info := &types.Info{Defs: map[*ast.Ident]types.Object{}}
+	conf := types.Config{
+		Importer: importer.Default(),
+		Error:    func(error) {},
+	}
+	_, _ = conf.Check(astPkg.Name, fset, files, info)
+
+	pkg := &errPackage{name: astPkg.Name}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				vspec := spec.(*ast.ValueSpec)
+				for _, name := range vspec.Names {
+					obj, ok := info.Defs[name].(*types.Const)
+					if !ok || types.TypeString(obj.Type(), types.RelativeTo(obj.Pkg())) != typeName {
+						continue
+					}
+
+					code, ok := constant.Int64Val(obj.Val())
+					if !ok {
+						return nil, fmt.Errorf("%s: %s is not an integer constant", fset.Position(name.Pos()), name.Name)
+					}
+
+					ec := errCode{
+						name: name.Name,
+						code: int(code),
+						pos:  fset.Position(name.Pos()),
+					}
+					ec.http, ec.message = parseAnnotation(vspec.Doc, vspec.Comment)
+					if ec.http == 0 && len(gen.Specs) == 1 {
+						ec.http, ec.message = parseAnnotation(gen.Doc, nil)
+					}
+					ec.deprecated, ec.replacement = parseDeprecation(vspec.Doc, vspec.Comment)
+					if !ec.deprecated && len(gen.Specs) == 1 {
+						ec.deprecated, ec.replacement = parseDeprecation(gen.Doc)
+					}
+
+					pkg.codes = append(pkg.codes, ec)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(pkg.codes, func(i, j int) bool {
+		return pkg.codes[i].code < pkg.codes[j].code
+	})
+
+	return pkg, nil
+}
+
+// parseAnnotation 从常量的注释中解析 HTTP 状态码和外部错误文本，
+// 同行注释优先于上方注释。
+func parseAnnotation(groups ...*ast.CommentGroup) (int, string) {
+	for i := len(groups) - 1; i >= 0; i-- {
+		if groups[i] == nil {
+			continue
+		}
+
+		for _, line := range strings.Split(groups[i].Text(), "\n") {
+			m := annotation.FindStringSubmatch(strings.TrimSpace(line))
+			if m == nil {
+				continue
+			}
+
+			status, _ := strconv.Atoi(m[1])
+			return status, m[2]
+		}
+	}
+
+	return 0, ""
+}
+
+// parseDeprecation 从常量的注释中解析废弃标记以及替代的常量名
+func parseDeprecation(groups ...*ast.CommentGroup) (bool, string) {
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+
+		for _, line := range strings.Split(group.Text(), "\n") {
+			m := deprecation.FindStringSubmatch(strings.TrimSpace(line))
+			if m != nil {
+				return true, m[1]
+			}
+		}
+	}
+
+	return false, ""
+}
+
+// lookup 返回常量名为 name 的错误码
+func (p *errPackage) lookup(name string) (errCode, bool) {
+	for _, ec := range p.codes {
+		if ec.name == name {
+			return ec, true
+		}
+	}
+
+	return errCode{}, false
+}
+
+// validate 检查重复的错误码、缺少注释的常量、不允许的 HTTP 状态码以及不存在的替代常量
+func (p *errPackage) validate(allowed map[int]bool) error {
+	var problems []string
+
+	if len(p.codes) == 0 {
+		return fmt.Errorf("no error code constants found in package %s", p.name)
+	}
+
+	seen := map[int]errCode{}
+	for _, ec := range p.codes {
+		if ec.code == 0 {
+			problems = append(problems, fmt.Sprintf("%s: %s: code `0` is reserved as unknownCode error code", ec.pos, ec.name))
+		}
+
+		if prev, ok := seen[ec.code]; ok {
+			problems = append(problems, fmt.Sprintf("%s: %s: duplicate code %d, first defined by %s at %s", ec.pos, ec.name, ec.code, prev.name, prev.pos))
+		} else {
+			seen[ec.code] = ec
+		}
+
+		if ec.replacement != "" {
+			if r, ok := p.lookup(ec.replacement); !ok || r.code == ec.code {
+				problems = append(problems, fmt.Sprintf("%s: %s: replacement %s is not a different error code constant", ec.pos, ec.name, ec.replacement))
+			}
+		}
+
+		if ec.http == 0 {
+			problems = append(problems, fmt.Sprintf("%s: %s: missing `<http status>: <message>` annotation", ec.pos, ec.name))
+			continue
+		}
+
+		if len(allowed) > 0 && !allowed[ec.http] {
+			problems = append(problems, fmt.Sprintf("%s: %s: http status %d is not allowed", ec.pos, ec.name, ec.http))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
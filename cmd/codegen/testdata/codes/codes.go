@@ -0,0 +1,22 @@
+package codes
+
+const (
+	// ErrUserNotFound - 404: User not found.
+	ErrUserNotFound int = iota + 110001
+
+	ErrUserAlreadyExist // 400: User already exist.
+
+	// ErrUserNameInvalid - 400: Invalid user name.
+	//
+	// Deprecated: use ErrUserNameTooLong.
+	ErrUserNameInvalid
+
+	// ErrUserNameTooLong - 400: User name is too long.
+	ErrUserNameTooLong
+)
+
+// ErrDatabase - 500: Database error.
+const ErrDatabase int = 100101
+
+// notACode 的类型不是 int，不会生成
+const notACode = "ignored"
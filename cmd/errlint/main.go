@@ -0,0 +1,92 @@
+// errlint 检查错误码的使用规范。
+//
+// 用法：
+//
+//	errlint [flags] [packages]
+//
+// packages 默认为 ./...，建议一次分析整个模块，以便识别在其他包中注册的错误码。
+// 每个分析器都可以通过 -<analyzer> 参数启用或禁用，分析器的参数使用 -<analyzer>.<flag> 指定，例如：
+//
+//	errlint -uncoded=false -codes.catalog=codes.json ./...
+//
+// 与 go vet 相同，诊断信息以 file:line:col: message 的形式输出到标准错误，
+// 发现问题时 errlint 以状态码 1 退出。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tiandh987/errors/lint"
+)
+
+// Usage 打印使用说明
+func Usage() {
+	fmt.Fprintf(os.Stderr, "Usage of errlint:\n")
+	fmt.Fprintf(os.Stderr, "\terrlint [flags] [packages]\n")
+	fmt.Fprintf(os.Stderr, "Analyzers:\n")
+	for _, a := range lint.Analyzers() {
+		fmt.Fprintf(os.Stderr, "\t%-8s %s\n", a.Name, a.Doc)
+	}
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("errlint: ")
+
+	analyzers := lint.Analyzers()
+	enabled := map[*lint.Analyzer]*bool{}
+	for _, a := range analyzers {
+		enabled[a] = flag.Bool(a.Name, true, "enable "+a.Name+" analysis")
+
+		prefix := a.Name + "."
+		a.Flags.VisitAll(func(f *flag.Flag) {
+			flag.Var(f.Value, prefix+f.Name, f.Usage)
+		})
+	}
+
+	flag.Usage = Usage
+	flag.Parse()
+
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	var run []*lint.Analyzer
+	for _, a := range analyzers {
+		if *enabled[a] {
+			run = append(run, a)
+		}
+	}
+
+	prog, err := lint.Load(".", patterns...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	diags, err := lint.Run(prog, run...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 与 go vet 相同，诊断信息以 file:line:col: message 的形式输出到标准错误，
+	// 文件路径相对于当前目录
+	wd, _ := os.Getwd()
+	for _, d := range diags {
+		if rel, err := filepath.Rel(wd, d.Pos.Filename); err == nil && !strings.HasPrefix(rel, "..") {
+			d.Pos.Filename = rel
+		}
+		fmt.Fprintln(os.Stderr, d)
+	}
+
+	if len(diags) > 0 {
+		os.Exit(1)
+	}
+}
@@ -1,15 +1,13 @@
 package errors
 
 import (
-	"fmt"
 	"net/http"
-	"sync"
 )
 
 // 使用：
 // 	在使用该 errors 包的时候，需要调用 Register 或者 MustRegister，
-// 	将一个 Coder 注册到 errors 开辟的内存中，数据结构为：
-//		var codes = map[int]Coder{}
+// 	将一个 Coder 注册到默认的 Registry（defaultRegistry）中，
+//	需要隔离错误码命名空间时，可以使用 NewRegistry 创建独立的 Registry。
 
 // 该文件内容：
 //	1、定义 Coder 接口
@@ -19,15 +17,10 @@ import (
 //			这样可以防止后面注册的错误覆盖掉之前注册的错误。
 //			在实际开发中，建议使用MustRegister。
 //
-//	2、用于存储注册 Coder 的内存空间
+//	2、实现 Coder 接口的 defaultCoder 结构体
+//	   创建函数 NewCoder
 //
-//	3、实现 Coder 接口的 defaultCoder 结构体
-//
-//	4、预定义 Coder unknownCoder
-
-// codes contains a map of error codes to metadata.
-var codes = map[int]Coder{}
-var codeMux = &sync.Mutex{}
+//	3、预定义 Coder unknownCoder
 
 var (
 	unknownCoder defaultCoder = defaultCoder{
@@ -38,10 +31,6 @@ var (
 	}
 )
 
-func init() {
-	codes[unknownCoder.Code()] = unknownCoder
-}
-
 // =========================================================
 type Coder interface {
 	// 用于相关错误码的 HTTP 状态
@@ -66,32 +55,43 @@ type Coder interface {
 // Register 注册一个用户定义的错误码
 // 它将会覆盖已存在的相同 code
 func Register(coder Coder) {
-	if coder.Code() == 0 {
-		// 0 被该 error 包保留为 unknownCode 错误码
-		panic("code `0` is reserved by `github.com/tiandh987/errors` as unknownCode error code")
-	}
+	defaultRegistry.Register(coder)
+}
 
-	codeMux.Lock()
-	defer codeMux.Unlock()
+// MustRegister 注册用户定义的错误码
+// 当相同的 Code 已经存在或者校验失败时，将会引发 panic
+func MustRegister(coders ...Coder) {
+	defaultRegistry.MustRegister(coders...)
+}
 
-	codes[coder.Code()] = coder
+// TryRegister 将 coders 注册到默认 Registry，注册失败时返回错误。
+func TryRegister(coders ...Coder) error {
+	return defaultRegistry.TryRegister(coders...)
 }
 
-// Register 注册一个用户定义的错误码
-// 当相同的 Code 已经存在时，将会引发 panic
-func MustRegister(coder Coder) {
-	if coder.Code() == 0 {
-		panic("code `0` is reserved by `github.com/tiandh987/errors` as unknownCode error code")
-	}
+// Lookup 返回默认 Registry 中 code 对应的 Coder，code 未注册时 ok 为 false。
+func Lookup(code int) (Coder, bool) {
+	return defaultRegistry.Lookup(code)
+}
 
-	codeMux.Lock()
-	defer codeMux.Unlock()
+// Unregister 删除默认 Registry 中 code 的注册，主要用于测试。
+func Unregister(code int) bool {
+	return defaultRegistry.Unregister(code)
+}
 
-	if _, ok := codes[coder.Code()]; ok {
-		panic(fmt.Sprintf("code: %d already exist", coder.Code()))
-	}
+// Freeze 冻结默认 Registry，之后注册错误码将会引发 panic。
+func Freeze() {
+	defaultRegistry.Freeze()
+}
+
+// Codes 返回默认 Registry 中所有已注册 Coder 的快照，按照错误码排序。
+func Codes() []Coder {
+	return defaultRegistry.Codes()
+}
 
-	codes[coder.Code()] = coder
+// Range 按照错误码顺序对默认 Registry 中每个已注册的 Coder 调用 fn，fn 返回 false 时停止遍历。
+func Range(fn func(coder Coder) bool) {
+	defaultRegistry.Range(fn)
 }
 
 // =================================================
@@ -109,6 +109,16 @@ type defaultCoder struct {
 	Ref string
 }
 
+// NewCoder 使用给定的错误码、HTTP 状态码、外部错误文本以及 reference 文档创建 Coder。
+func NewCoder(code, httpStatus int, ext, ref string) Coder {
+	return defaultCoder{
+		C:    code,
+		HTTP: httpStatus,
+		Ext:  ext,
+		Ref:  ref,
+	}
+}
+
 func (coder defaultCoder) Code() int {
 	return coder.C
 }
@@ -132,33 +142,87 @@ func (coder defaultCoder) Reference() string {
 // ParseCoder 解析任何 error 为 *withCode。
 // nil error 将直接返回 nil
 // None withStack error will be parsed as ErrUnknown.
+//
+// 使用错误树（见 walk）中第一个 withCode 错误，该错误使用创建它的 Registry 解析，
+// 没有 withCode 错误时使用默认 Registry 解析。
 func ParseCoder(err error) Coder {
 	if err == nil {
 		return nil
 	}
 
-	if v, ok := err.(*withCode); ok {
-		if coder, ok := codes[v.code]; ok {
-			return coder
+	return registryOf(err).ParseCoder(err)
+}
+
+// IsCode 报告错误树中是否包含给定的错误代码，不区分错误所属的 Registry，
+// 别名与新错误码视为相同（使用渲染每个错误的 Registry 解析别名）。
+// 错误树中其他包的包装错误（实现了 Unwrap() error 或 Unwrap() []error）以及聚合错误也会被展开。
+func IsCode(err error, code int) bool {
+	return walk(err, func(e error) bool {
+		v, ok := e.(*withCode)
+		if !ok {
+			return false
 		}
+
+		state := v.renderer().load()
+		return state.resolve(v.code) == state.resolve(code)
+	})
+}
+
+// unwrap 返回 err 包装的错误，err 没有包装错误时返回 nil。
+// 用于沿错误链穿过其他包的包装错误，例如 *url.Error。
+func unwrap(err error) error {
+	if w, ok := err.(interface{ Unwrap() error }); ok {
+		return w.Unwrap()
 	}
 
-	return unknownCoder
+	return nil
 }
 
-// IsCode 报告错误链中是否包含给定的错误代码。
-func IsCode(err error, code int) bool {
-	if v, ok := err.(*withCode); ok {
-		if v.code == code {
-			return true
+// unwrapAll 返回 err 包装的所有错误：
+// 实现了 Unwrap() []error 的错误（例如 Join、包含多个 %w 的 fmt.Errorf）以及聚合错误返回其中的每个错误，
+// 实现了 Unwrap() error 的错误返回它包装的错误。
+func unwrapAll(err error) []error {
+	switch w := err.(type) {
+	case interface{ Unwrap() []error }:
+		return w.Unwrap()
+	case Aggregate:
+		return w.Errors()
+	case interface{ Unwrap() error }:
+		if e := w.Unwrap(); e != nil {
+			return []error{e}
 		}
+	}
 
-		if v.cause != nil {
-			return IsCode(v.cause, code)
-		}
+	return nil
+}
 
+// walk 按照深度优先、先外层后内层的顺序遍历 err 的错误树，直到 fn 返回 true，
+// 返回 fn 是否返回过 true。err 为 nil 时返回 false。
+func walk(err error, fn func(err error) bool) bool {
+	if err == nil {
 		return false
 	}
 
+	if fn(err) {
+		return true
+	}
+
+	for _, e := range unwrapAll(err) {
+		if walk(e, fn) {
+			return true
+		}
+	}
+
 	return false
 }
+
+// firstCoded 返回 err 的错误树中第一个 withCode 错误
+func firstCoded(err error) (*withCode, bool) {
+	var coded *withCode
+	walk(err, func(e error) bool {
+		coded, _ = e.(*withCode)
+		return coded != nil
+	})
+
+	return coded, coded != nil
+}
@@ -0,0 +1,181 @@
+// Package docgen 根据错误码目录生成文档：一个 Markdown 页面和一个自包含的 HTML 页面，
+// 列出每个错误码的 HTTP 状态码、外部错误文本以及 reference 文档。
+//
+// 文档的数据来源既可以是 Registry.Catalog()，
+// 也可以是从源码中提取的静态目录（例如 cmd/codegen）。
+// 每个错误码的锚点为 errors.Anchor(code)，只与错误码有关，保持稳定，
+// 因此 errors.ReferenceURL(base, code) 可以作为 Coder.Reference() 链接到文档中的某个错误码。
+//
+// 该包独立于 errors 包，只有生成文档的程序才会引入 html/template。
+package docgen
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tiandh987/errors"
+)
+
+// 文件内容：
+//	1、WriteMarkdown() 生成 Markdown 文档
+//	2、WriteHTML() 生成自包含的 HTML 文档
+
+// sortCatalog 按照错误码对目录排序
+func sortCatalog(entries []errors.CatalogEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Code < entries[j].Code
+	})
+}
+
+// ===================================================================
+// WriteMarkdown 将错误码目录以 Markdown 表格的形式写入 w。
+func WriteMarkdown(w io.Writer, title string, entries []errors.CatalogEntry) error {
+	entries = append([]errors.CatalogEntry(nil), entries...)
+	sortCatalog(entries)
+
+	withName := hasName(entries)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if withName {
+		b.WriteString("| Identifier | Code | HTTP Status | Message | Reference |\n")
+		b.WriteString("| ---------- | ---- | ----------- | ------- | --------- |\n")
+	} else {
+		b.WriteString("| Code | HTTP Status | Message | Reference |\n")
+		b.WriteString("| ---- | ----------- | ------- | --------- |\n")
+	}
+
+	for _, e := range entries {
+		b.WriteString("| ")
+		if withName {
+			fmt.Fprintf(&b, "%s | ", markdownEscape(e.Name))
+		}
+		fmt.Fprintf(&b, "<a id=\"%s\"></a>", errors.Anchor(e.Code))
+		for _, old := range e.Aliases {
+			fmt.Fprintf(&b, "<a id=\"%s\"></a>", errors.Anchor(old))
+		}
+		fmt.Fprintf(&b, "%d | %d | %s%s%s | %s |\n",
+			e.Code,
+			e.HTTP,
+			markdownDeprecated(e),
+			markdownEscape(e.Message),
+			markdownAliases(e.Aliases),
+			markdownLink(e.Reference),
+		)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// hasName 报告目录中是否有定义包含标识符
+func hasName(entries []errors.CatalogEntry) bool {
+	for _, e := range entries {
+		if e.Name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+var markdownReplacer = strings.NewReplacer("|", "\\|", "\n", " ", "<", "&lt;", ">", "&gt;")
+
+// markdownEscape 转义 Markdown 表格单元格中的特殊字符
+func markdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+// markdownDeprecated 返回废弃错误码的标记
+func markdownDeprecated(e errors.CatalogEntry) string {
+	if !e.Deprecated {
+		return ""
+	}
+
+	if e.Replacement != 0 {
+		return fmt.Sprintf("**Deprecated**, use [%d](#%s). ", e.Replacement, errors.Anchor(e.Replacement))
+	}
+	return "**Deprecated**. "
+}
+
+// markdownAliases 返回别名列表
+func markdownAliases(aliases []int) string {
+	if len(aliases) == 0 {
+		return ""
+	}
+
+	return " (aliases: " + joinInts(aliases) + ")"
+}
+
+// joinInts 使用 ", " 连接整数
+func joinInts(values []int) string {
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		strs = append(strs, strconv.Itoa(v))
+	}
+
+	return strings.Join(strs, ", ")
+}
+
+// markdownLink 将 reference 渲染为 Markdown 链接
+func markdownLink(ref string) string {
+	if ref == "" {
+		return ""
+	}
+	return fmt.Sprintf("[%s](%s)", markdownEscape(ref), strings.ReplaceAll(ref, " ", "%20"))
+}
+
+// ===================================================================
+var htmlTemplate = template.Must(template.New("errcode").Funcs(template.FuncMap{
+	"anchor": errors.Anchor,
+	"join":   joinInts,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292e; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #dfe2e5; padding: 6px 13px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+tr:target { background: #fff8c5; }
+.deprecated { color: #cb2431; font-weight: 600; }
+a.anchor { color: inherit; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<thead>
+<tr>{{if .WithName}}<th>Identifier</th>{{end}}<th>Code</th><th>HTTP Status</th><th>Message</th><th>Reference</th></tr>
+</thead>
+<tbody>
+{{- $withName := .WithName}}
+{{- range .Entries}}
+<tr id="{{anchor .Code}}">{{if $withName}}<td>{{.Name}}</td>{{end}}<td><a class="anchor" href="#{{anchor .Code}}">{{.Code}}</a>{{range .Aliases}}<span id="{{anchor .}}"></span>{{end}}</td><td>{{.HTTP}}</td><td>{{if .Deprecated}}<span class="deprecated">Deprecated</span>{{if .Replacement}}, use <a href="#{{anchor .Replacement}}">{{.Replacement}}</a>{{end}}. {{end}}{{.Message}}{{if .Aliases}} (aliases: {{join .Aliases}}){{end}}</td><td>{{if .Reference}}<a href="{{.Reference}}">{{.Reference}}</a>{{end}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// WriteHTML 将错误码目录以自包含 HTML 页面的形式写入 w。
+func WriteHTML(w io.Writer, title string, entries []errors.CatalogEntry) error {
+	entries = append([]errors.CatalogEntry(nil), entries...)
+	sortCatalog(entries)
+
+	return htmlTemplate.Execute(w, struct {
+		Title    string
+		WithName bool
+		Entries  []errors.CatalogEntry
+	}{
+		Title:    title,
+		WithName: hasName(entries),
+		Entries:  entries,
+	})
+}
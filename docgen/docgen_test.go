@@ -0,0 +1,139 @@
+package docgen
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tiandh987/errors"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// checkGolden 比较 got 与 testdata 中的 golden 文件，-update 时更新 golden 文件
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from %s, run go test -update to update it\ngot:\n%s", name, path, got)
+	}
+}
+
+// testCatalog 返回测试用的目录，故意不按错误码排序
+func testCatalog() []errors.CatalogEntry {
+	return []errors.CatalogEntry{
+		{
+			Name:    "ErrInvalidName",
+			Code:    110003,
+			HTTP:    400,
+			Message: "Invalid name | <name> must not be empty",
+			Aliases: []int{110002},
+		},
+		{
+			Name:      "ErrUserNotFound",
+			Code:      110001,
+			HTTP:      404,
+			Message:   "User not found",
+			Reference: "https://example.com/errors.html#code-110001",
+			Site:      "user/codes.go:12",
+		},
+		{
+			Name:        "ErrLegacy",
+			Code:        110004,
+			HTTP:        500,
+			Message:     "Legacy error",
+			Deprecated:  true,
+			Replacement: 110003,
+		},
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteMarkdown(&b, "Error Codes", testCatalog()); err != nil {
+		t.Fatalf("WriteMarkdown() = %v", err)
+	}
+
+	checkGolden(t, "catalog.md", b.Bytes())
+}
+
+func TestWriteHTML(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteHTML(&b, "Error Codes", testCatalog()); err != nil {
+		t.Fatalf("WriteHTML() = %v", err)
+	}
+
+	checkGolden(t, "catalog.html", b.Bytes())
+}
+
+func TestWriteDoesNotReorderInput(t *testing.T) {
+	entries := testCatalog()
+
+	if err := WriteMarkdown(ioutil.Discard, "Error Codes", entries); err != nil {
+		t.Fatalf("WriteMarkdown() = %v", err)
+	}
+	if err := WriteHTML(ioutil.Discard, "Error Codes", entries); err != nil {
+		t.Fatalf("WriteHTML() = %v", err)
+	}
+
+	if entries[0].Code != 110003 {
+		t.Errorf("entries reordered, entries[0].Code = %d", entries[0].Code)
+	}
+}
+
+// 锚点只与错误码有关：文档中的锚点、ReferenceURL 生成的链接以及别名和替代错误码的链接必须一致
+func TestAnchorStability(t *testing.T) {
+	if got := errors.Anchor(110001); got != "code-110001" {
+		t.Errorf("Anchor(110001) = %q, want %q", got, "code-110001")
+	}
+
+	for _, base := range []string{"https://example.com/errors.html", "https://example.com/errors.html#"} {
+		if got, want := errors.ReferenceURL(base, 110001), "https://example.com/errors.html#code-110001"; got != want {
+			t.Errorf("ReferenceURL(%q, 110001) = %q, want %q", base, got, want)
+		}
+	}
+
+	var md, html bytes.Buffer
+	if err := WriteMarkdown(&md, "Error Codes", testCatalog()); err != nil {
+		t.Fatalf("WriteMarkdown() = %v", err)
+	}
+	if err := WriteHTML(&html, "Error Codes", testCatalog()); err != nil {
+		t.Fatalf("WriteHTML() = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"code", `id="code-110001"`},
+		{"alias", `id="code-110002"`},
+		{"replacement link", `#code-110003`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(md.String(), tt.want) {
+				t.Errorf("markdown does not contain %q:\n%s", tt.want, md.String())
+			}
+			if !strings.Contains(html.String(), tt.want) {
+				t.Errorf("html does not contain %q:\n%s", tt.want, html.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// 文件内容：
+//	1、Encode()、Decode() 跨进程传递错误
+//		用于消息队列、任务存储等场景，编码的格式为 ErrorRecord 的 JSON 表示（见 marshal.go）。
+//
+//	2、自定义错误类型：RegisterErrorType()、MustRegisterErrorType()
+//		为应用自己的错误类型注册编码、解码函数，编码后 kind 为 custom，type 为注册的名称，
+//		data 为编码函数返回的数据。
+//
+// Decode 返回 *JSONError，其错误链保留每一层错误的类型（fundamental、withStack、withMessage、
+// withCode、withFields、aggregate、注册的自定义类型）、错误码和错误信息，
+// 调用栈保存在 JSONError.Record() 中，格式化时代替本地的调用栈输出，再次 Encode 时原样输出。
+// data 损坏时 Decode 返回 cause 为 ErrMalformedEncoding 的错误。
+// 没有注册的其他包的错误类型解码为 *OpaqueError，保留原错误的类型名和错误信息；
+// 自定义类型在解码的进程中没有注册或者解码失败时同样解码为 *OpaqueError。
+//
+// 使用：
+//	errors.MustRegisterErrorType("myapp.QuotaError", &QuotaError{}, errors.ErrorCodec{
+//		Encode: func(err error) ([]byte, error) { return json.Marshal(err) },
+//		Decode: func(data []byte, cause error) (error, error) {
+//			e := &QuotaError{}
+//			return e, json.Unmarshal(data, e)
+//		},
+//	})
+//	data := errors.Encode(err)
+//	err = errors.Decode(data)
+
+// kindCustom 是注册的自定义错误类型的 kind
+const kindCustom = "custom"
+
+// ErrorCodec 是自定义错误类型的编码、解码函数。
+type ErrorCodec struct {
+	// Encode 编码 err，err 的类型与注册时的类型相同，返回错误时 err 被编码为 opaque
+	Encode func(err error) ([]byte, error)
+
+	// Decode 根据 Encode 返回的数据重建错误，cause 为 err 包装的错误（实现了 Unwrap() error 时），可以为 nil。
+	// 返回错误时解码为 *OpaqueError。
+	Decode func(data []byte, cause error) (error, error)
+}
+
+// errorType 是注册的自定义错误类型
+type errorType struct {
+	name  string
+	codec ErrorCodec
+}
+
+// errorTypes 保存注册的自定义错误类型
+var errorTypes = struct {
+	sync.RWMutex
+	byType map[reflect.Type]*errorType
+	byName map[string]*errorType
+}{
+	byType: map[reflect.Type]*errorType{},
+	byName: map[string]*errorType{},
+}
+
+// RegisterErrorType 为 sample 的类型注册编码、解码函数，name 是编码中的类型名，在所有进程中必须相同。
+// name 或者类型已经注册时返回错误。
+func RegisterErrorType(name string, sample error, codec ErrorCodec) error {
+	if name == "" || sample == nil || codec.Encode == nil || codec.Decode == nil {
+		return New("errors: register error type: name, sample, Encode and Decode are required")
+	}
+
+	typ := reflect.TypeOf(sample)
+
+	errorTypes.Lock()
+	defer errorTypes.Unlock()
+
+	if _, ok := errorTypes.byName[name]; ok {
+		return Errorf("errors: error type name %q already registered", name)
+	}
+
+	if prev, ok := errorTypes.byType[typ]; ok {
+		return Errorf("errors: error type %s already registered as %q", typ, prev.name)
+	}
+
+	t := &errorType{name: name, codec: codec}
+	errorTypes.byType[typ] = t
+	errorTypes.byName[name] = t
+
+	return nil
+}
+
+// MustRegisterErrorType 与 RegisterErrorType 相同，失败时将会引发 panic。
+func MustRegisterErrorType(name string, sample error, codec ErrorCodec) {
+	if err := RegisterErrorType(name, sample, codec); err != nil {
+		panic(err.Error())
+	}
+}
+
+// lookupErrorType 返回 err 的类型注册的自定义错误类型
+func lookupErrorType(err error) (*errorType, bool) {
+	errorTypes.RLock()
+	defer errorTypes.RUnlock()
+
+	t, ok := errorTypes.byType[reflect.TypeOf(err)]
+	return t, ok
+}
+
+// lookupErrorTypeName 返回名称为 name 的自定义错误类型
+func lookupErrorTypeName(name string) (*errorType, bool) {
+	errorTypes.RLock()
+	defer errorTypes.RUnlock()
+
+	t, ok := errorTypes.byName[name]
+	return t, ok
+}
+
+// customRecord 使用注册的编码函数编码 err，err 的类型没有注册或者编码失败时 ok 为 false
+func customRecord(err error) (rec *ErrorRecord, ok bool) {
+	t, ok := lookupErrorType(err)
+	if !ok {
+		return nil, false
+	}
+
+	data, encErr := t.codec.Encode(err)
+	if encErr != nil || !json.Valid(data) {
+		return nil, false
+	}
+
+	return &ErrorRecord{
+		Kind:    kindCustom,
+		Type:    t.name,
+		Message: errorText(err),
+		Data:    data,
+		Cause:   NewErrorRecord(unwrap(err)),
+	}, true
+}
+
+// customErr 使用注册的解码函数重建 rec，类型没有注册或者解码失败时 ok 为 false
+func (rec *ErrorRecord) customErr(cause error) (err error, ok bool) {
+	t, ok := lookupErrorTypeName(rec.Type)
+	if !ok {
+		return nil, false
+	}
+
+	err, decErr := t.codec.Decode(rec.Data, cause)
+	if decErr != nil || err == nil {
+		return nil, false
+	}
+
+	return err, true
+}
+
+// ===================================================================
+// Encode 将 err 编码为 ErrorRecord 的 JSON 表示，err 为 nil 时返回 nil。
+// 字段、模板参数等无法编码为 JSON 时，err 被编码为只包含类型名和错误信息的 opaque。
+func Encode(err error) []byte {
+	if err == nil {
+		return nil
+	}
+
+	data, jsonErr := json.Marshal(NewErrorRecord(err))
+	if jsonErr != nil {
+		data, _ = json.Marshal(&ErrorRecord{Kind: kindOpaque, Type: fmt.Sprintf("%T", err), Message: errorText(err)})
+	}
+
+	return data
+}
+
+// ErrMalformedEncoding 是 Decode 的 data 不是合法的编码时返回的错误的 cause，
+// 用于区分解码失败与解码得到的错误：
+//
+//	err := errors.Decode(data)
+//	if errors.Is(err, errors.ErrMalformedEncoding) {
+//		// data 已经损坏
+//	}
+var ErrMalformedEncoding = New("errors: malformed encoded error")
+
+// Decode 解码 Encode 编码的错误，返回 *JSONError，data 为空或者 null 时返回 nil。
+// data 不是合法的编码时返回 cause 为 ErrMalformedEncoding 的错误，错误信息包含解析失败的原因。
+func Decode(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	e := &JSONError{}
+	if err := e.UnmarshalJSON(data); err != nil {
+		return WithMessagef(ErrMalformedEncoding, "%s: %v", ErrMalformedEncoding.Error(), err)
+	}
+
+	return e
+}
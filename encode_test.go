@@ -0,0 +1,73 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	MustRegister(NewCoder(180001, 404, "Task not found", ""))
+	defer Unregister(180001)
+
+	err := WrapC(Wrap(New("no rows"), "load task"), 180001, "run task %d", 3)
+	decoded := Decode(Encode(err))
+
+	if Is(decoded, ErrMalformedEncoding) {
+		t.Fatalf("Decode() = %v, want the decoded error", decoded)
+	}
+	if !IsCode(decoded, 180001) {
+		t.Errorf("IsCode(Decode(), 180001) = false")
+	}
+	if got, want := fmt.Sprintf("%s", decoded), fmt.Sprintf("%s", err); got != want {
+		t.Errorf("%%s = %q, want %q", got, want)
+	}
+
+	// 记录的远程调用栈代替本地的调用栈输出
+	for _, verb := range []string{"%-v", "%+v", "%#-v", "%#+v"} {
+		got, want := fmt.Sprintf(verb, decoded), fmt.Sprintf(verb, err)
+		if got != want {
+			t.Errorf("%s = %s\nwant %s", verb, got, want)
+		}
+		if !strings.Contains(got, "encode_test.go") {
+			t.Errorf("%s = %s, missing the recorded caller", verb, got)
+		}
+	}
+
+	root := New("root")
+	if got, want := fmt.Sprintf("%+v", Decode(Encode(root))), fmt.Sprintf("%+v", root); got != want {
+		t.Errorf("%%+v of a decoded fundamental = %s\nwant %s", got, want)
+	}
+
+	// 再次编码时原样输出记录的调用栈
+	var rec, again ErrorRecord
+	if err := json.Unmarshal(Encode(err), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(Encode(NewErrorRecord(decoded).Err()), &again); err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Frames) == 0 || strings.Join(again.Frames, "\n") != strings.Join(rec.Frames, "\n") {
+		t.Errorf("re-encoded frames = %q, want %q", again.Frames, rec.Frames)
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	for _, data := range []string{"{", `{"kind": 1}`, "not json"} {
+		err := Decode([]byte(data))
+		if !Is(err, ErrMalformedEncoding) {
+			t.Errorf("Decode(%q) = %v, want ErrMalformedEncoding", data, err)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), "errors: malformed encoded error: ") {
+			t.Errorf("Decode(%q) error = %q", data, err)
+		}
+	}
+
+	for _, data := range []string{"", "null", "  "} {
+		if err := Decode([]byte(data)); err != nil {
+			t.Errorf("Decode(%q) = %v, want nil", data, err)
+		}
+	}
+}
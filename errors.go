@@ -31,15 +31,51 @@ import (
 // withCode 引入一种新的错误类型，
 // 该错误类型记录错误码、stack、cause、具体的错误信息。
 type withCode struct {
-	err    error // error 错误
-	code   int   // 业务错误码
-	cause  error // cause error
-	*stack       // 错误堆栈
+	err      error                  // error 错误
+	code     int                    // 业务错误码
+	cause    error                  // cause error
+	registry *Registry              // 渲染该错误的 Registry，nil 表示默认 Registry
+	coder    Coder                  // 远程错误的 Coder，不为 nil 时替代 Registry 中的 Coder
+	params   Params                 // 填充外部错误文本模板的参数
+	fields   map[string]interface{} // 结构化字段，见 fields.go
+	*stack                          // 错误堆栈
+	frames   []string               // 解码的远程错误记录的调用栈，见 marshal.go
+}
+
+// renderer 返回渲染该错误的 Registry
+func (w *withCode) renderer() *Registry {
+	if w.registry == nil {
+		return defaultRegistry
+	}
+	return w.registry
+}
+
+// coderIn 返回渲染该错误使用的 Coder，s 是渲染该错误的 Registry 的状态
+func (w *withCode) coderIn(s *registryState) Coder {
+	if w.coder != nil {
+		return w.coder
+	}
+	return s.coder(w.code)
 }
 
 // Error 返回外部安全的错误信息
 func (w *withCode) Error() string {
-	return fmt.Sprintf("%v", w)
+	w.renderer().renderedErr(w)
+	return w.text()
+}
+
+// text 返回 Error() 的结果，不计入渲染次数
+func (w *withCode) text() string {
+	return fmt.Sprintf("%v", uncounted{w})
+}
+
+// uncounted 格式化 withCode 错误，不计入渲染次数
+type uncounted struct {
+	w *withCode
+}
+
+func (u uncounted) Format(state fmt.State, verb rune) {
+	u.w.format(state, verb, u.w)
 }
 
 // Cause 返回 withCode 错误的根因
@@ -59,7 +95,7 @@ func (w withCode) Unwrap() error {
 // 		%v - %s 的别名
 //
 // Flags：
-// 		# JSON 格式的输出，用于记录日志
+// 		# JSON 格式的输出，用于记录日志，与 - 或 + 一起使用时输出错误的字段（见 fields.go）
 // 		- 输出调用者详细信息，对故障排除有用
 // 		+ 输出完整的错误堆栈详细信息，对调试很有用
 //
@@ -72,6 +108,13 @@ func (w withCode) Unwrap() error {
 //      %#-v:  [{"caller":"#0 /home/lk/workspace/golang/src/github.com/marmotedu/iam/main.go:12 (main.main)","error":"error for internal read B","message":"(#100102) Internal Server Error"}]
 //      %#+v:  [{"caller":"#0 /home/lk/workspace/golang/src/github.com/marmotedu/iam/main.go:12 (main.main)","error":"error for internal read B","message":"(#100102) Internal Server Error"},{"caller":"#1 /home/lk/workspace/golang/src/github.com/marmotedu/iam/main.go:35 (main.newErrorB)","error":"error for internal read A","message":"(#100104) Validation failed"}]
 func (w *withCode) Format(state fmt.State, verb rune) {
+	w.renderer().renderedErr(w)
+	w.format(state, verb, w)
+}
+
+// format 实现 Format，top 为包装 w 的最外层错误，JSON 输出中第一个错误的字段从 top 开始合并，不计入渲染次数
+func (w *withCode) format(state fmt.State, verb rune, top error) {
+
 	switch verb {
 	case 'v':
 		str := bytes.NewBuffer([]byte{})
@@ -96,10 +139,18 @@ func (w *withCode) Format(state fmt.State, verb rune) {
 		}
 
 		sep := ""
+		snaps := snapshots{}
 		errs := list(w)
 		length := len(errs)
 		for k, e := range errs {
-			finfo := buildFormatInfo(e)
+			finfo := buildFormatInfo(e, snaps)
+			if modeJSON && (flagDetail || flagTrace) {
+				if k == 0 {
+					finfo.fields = Fields(top)
+				} else {
+					finfo.fields = Fields(e)
+				}
+			}
 			jsonData, str = format(length-k-1, jsonData, str, finfo, sep, flagDetail, flagTrace, modeJSON)
 			sep = "; "
 
@@ -121,13 +172,15 @@ func (w *withCode) Format(state fmt.State, verb rune) {
 
 		fmt.Fprintf(state, "%s", strings.Trim(str.String(), "\r\n\t"))
 	default:
-		finfo := buildFormatInfo(w)
-		fmt.Fprintf(state, finfo.message)
+		finfo := buildFormatInfo(w, snapshots{})
+		io.WriteString(state, finfo.message)
 	}
 }
 
 // WithCode 函数创建新的 withCode 类型的错误
 func WithCode(code int, format string, args ...interface{}) error {
+	defaultRegistry.created(code)
+
 	return &withCode{
 		err:   fmt.Errorf(format, args...),
 		code:  code,
@@ -194,6 +247,7 @@ func WithMessagef(err error, format string, args ...interface{}) error {
 type withStack struct {
 	error
 	*stack
+	frames []string // 解码的远程错误记录的调用栈，见 marshal.go
 }
 
 func (w *withStack) Cause() error {
@@ -212,8 +266,9 @@ func (w withStack) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		if s.Flag('+') {
-			fmt.Fprint(s, "%+v", w.Cause())
+			fmt.Fprintf(s, "%+v", w.Cause())
 			w.stack.Format(s, verb)
+			formatFrames(s, w.frames)
 			return
 		}
 		fallthrough
@@ -233,10 +288,13 @@ func WithStack(err error) error {
 
 	if e, ok := err.(*withCode); ok {
 		return &withCode{
-			err:   e.err,
-			code:  e.code,
-			cause: err,
-			stack: callers(),
+			err:      e.err,
+			code:     e.code,
+			cause:    err,
+			registry: e.registry,
+			coder:    e.coder,
+			params:   e.params,
+			stack:    callers(),
 		}
 	}
 
@@ -252,6 +310,7 @@ func WithStack(err error) error {
 type fundamental struct {
 	msg string
 	*stack
+	frames []string // 解码的远程错误记录的调用栈，见 marshal.go
 }
 
 func (f *fundamental) Error() string {
@@ -264,6 +323,7 @@ func (f *fundamental) Format(s fmt.State, verb rune) {
 		if s.Flag('+') {
 			io.WriteString(s, f.msg)
 			f.stack.Format(s, verb)
+			formatFrames(s, f.frames)
 			return
 		}
 		fallthrough
@@ -302,10 +362,13 @@ func Wrap(err error, message string) error {
 
 	if e, ok := err.(*withCode); ok {
 		return &withCode{
-			err:   fmt.Errorf(message),
-			code:  e.code,
-			cause: err,
-			stack: callers(),
+			err:      fmt.Errorf(message),
+			code:     e.code,
+			cause:    err,
+			registry: e.registry,
+			coder:    e.coder,
+			params:   e.params,
+			stack:    callers(),
 		}
 	}
 
@@ -324,6 +387,7 @@ func WrapC(err error, code int, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
 	}
+	defaultRegistry.created(code)
 
 	return &withCode{
 		err:   fmt.Errorf(format, args...),
@@ -342,10 +406,13 @@ func Wrapf(err error, format string, args ...interface{}) error {
 
 	if e, ok := err.(*withCode); ok {
 		return &withCode{
-			err:   fmt.Errorf(format, args...),
-			code:  e.code,
-			cause: err,
-			stack: callers(),
+			err:      fmt.Errorf(format, args...),
+			code:     e.code,
+			cause:    err,
+			registry: e.registry,
+			coder:    e.coder,
+			params:   e.params,
+			stack:    callers(),
 		}
 	}
 
@@ -354,7 +421,7 @@ func Wrapf(err error, format string, args ...interface{}) error {
 		msg:   fmt.Sprintf(format, args...),
 	}
 	return &withStack{
-		err,
-		callers(),
+		error: err,
+		stack: callers(),
 	}
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/tiandh987/errors"
+)
+
+// 演示使用 ErrorHandler 将 HandlerFunc 返回的错误以及 panic 写入 HTTP 响应。
+//
+// 运行：
+//	go run ./example/http
+
+const (
+	ErrUserNotFound = 110001
+	ErrRateLimited  = 110002
+)
+
+// rateLimited 声明了 Retry-After 头
+type rateLimited struct {
+	errors.Coder
+}
+
+func (rateLimited) Header() http.Header {
+	return http.Header{"Retry-After": {"30"}}
+}
+
+func main() {
+	r := errors.NewRegistry()
+	r.MustRegister(errors.NewCoder(ErrUserNotFound, 404, "User not found", "https://example.com/errors#code-110001"))
+	r.MustRegister(rateLimited{errors.NewCoder(ErrRateLimited, 429, "Too many requests", "")})
+	r.AddMessages("zh", map[int]string{ErrUserNotFound: "用户不存在"})
+
+	h := &errors.ErrorHandler{
+		Registry: r,
+		Logf: func(format string, args ...interface{}) {
+			fmt.Printf("log: "+format+"\n", args...)
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/users/", h.Handle(func(w http.ResponseWriter, req *http.Request) error {
+		id := strings.TrimPrefix(req.URL.Path, "/users/")
+		return r.WrapC(errors.New("sql: no rows in result set"), ErrUserNotFound, "user %s not found", id)
+	}))
+	mux.Handle("/limited", h.Handle(func(w http.ResponseWriter, req *http.Request) error {
+		return r.WithCode(ErrRateLimited, "quota exceeded")
+	}))
+	mux.Handle("/panic", h.Handle(func(w http.ResponseWriter, req *http.Request) error {
+		var m map[string]int
+		m["boom"]++
+		return nil
+	}))
+
+	for _, path := range []string{"/users/42", "/limited", "/panic"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(errors.RequestIDHeader, "req-1")
+		req.Header.Set("Accept-Language", "zh-CN,en;q=0.8")
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		fmt.Printf("%s => %d Retry-After=%q %s\n", path, rec.Code, rec.Header().Get("Retry-After"), rec.Body)
+	}
+}
+
+// 输出（go run ./example/http，省略了 panic 的 goroutine 调用栈，$GOPATH/src/github.com/tiandh987/errors 记为 .../errors）：
+//	log: GET /users/42 (request req-1): user 42 not found - #1 [.../errors/example/http/main.go:47 (main.main.func2)] (110001) User not found; sql: no rows in result set - #0 [.../errors/example/http/main.go:47 (main.main.func2)] (0) sql: no rows in result set
+//	/users/42 => 404 Retry-After="" {"code":110001,"message":"用户不存在","reference":"https://example.com/errors#code-110001","requestId":"req-1"}
+//
+//	log: GET /limited (request req-1): quota exceeded - #0 [.../errors/example/http/main.go:50 (main.main.func3)] (110002) Too many requests
+//	/limited => 429 Retry-After="30" {"code":110002,"message":"Too many requests","requestId":"req-1"}
+//
+//	log: GET /panic (request req-1): panic: assignment to entry in nil map
+//	goroutine 1 [running]:
+//	...
+//	log: GET /panic (request req-1): panic: assignment to entry in nil map - #0 [.../errors/example/http/main.go:54 (main.main.func4)] (0) An internal server error occurred
+//	/panic => 500 Retry-After="" {"code":0,"message":"An internal server error occurred","reference":"http://github.com/tiandh987/errors/README.md","requestId":"req-1"}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tiandh987/errors"
+)
+
+// 演示使用独立的 Registry 注册错误码、冻结之后创建并解析错误。
+//
+// 运行：
+//	go run ./example/registry
+
+const (
+	ErrUserNotFound = 110001
+	ErrUserExists   = 110002
+)
+
+func main() {
+	r := errors.NewRegistry()
+	r.MustRegister(errors.NewCoder(ErrUserNotFound, 404, "User not found", ""))
+	r.MustRegister(errors.NewCoder(ErrUserExists, 409, "User already exists", ""))
+
+	// 所有错误码注册完成之后冻结，之后的注册都会失败
+	r.Freeze()
+	if err := r.TryRegister(errors.NewCoder(110003, 400, "Too late", "")); err != nil {
+		fmt.Println("register after freeze:", err)
+	}
+
+	err := r.WrapC(errors.New("record not found"), ErrUserNotFound, "get user %q", "alice")
+
+	coder := r.ParseCoder(err)
+	fmt.Println(coder.Code(), coder.HTTPStatus(), coder.String())
+	fmt.Println(r.IsCode(err, ErrUserNotFound))
+	fmt.Printf("%s\n", err)
+	fmt.Printf("%-v\n", err)
+}
+
+// 输出：
+//	register after freeze: registry is frozen
+//	110001 404 User not found
+//	true
+//	User not found
+//	get user "alice" - #1 [...] (110001) User not found
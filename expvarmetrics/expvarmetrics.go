@@ -0,0 +1,39 @@
+// Package expvarmetrics 通过 expvar 导出 errors.Registry 的错误码计数。
+//
+// expvar 包在初始化时会在 http.DefaultServeMux 上注册 /debug/vars，
+// 因此 errors 包本身不引入 expvar，需要通过 expvar 导出计数的程序引入该包：
+//
+//	errors.EnableMetrics()
+//	expvarmetrics.Publish("errors", nil)
+//
+// 或者自己发布 Func 返回的 expvar.Func：
+//
+//	expvar.Publish("errors", expvarmetrics.Func(registry))
+package expvarmetrics
+
+import (
+	"expvar"
+
+	"github.com/tiandh987/errors"
+)
+
+// 文件内容：
+//	1、Func() 返回输出 Registry 计数的 expvar.Func
+//	2、Publish() 将 Registry 的计数发布到 expvar
+
+// Func 返回值为 r.Metrics() 的 expvar.Func，r 为 nil 时使用默认 Registry。
+func Func(r *errors.Registry) expvar.Func {
+	if r == nil {
+		r = errors.DefaultRegistry()
+	}
+
+	return expvar.Func(func() interface{} {
+		return r.Metrics()
+	})
+}
+
+// Publish 将 r 的计数以 name 为名称发布到 expvar，r 为 nil 时使用默认 Registry。
+// 与 expvar.Publish 相同，name 已经发布时将会引发 panic。
+func Publish(name string, r *errors.Registry) {
+	expvar.Publish(name, Func(r))
+}
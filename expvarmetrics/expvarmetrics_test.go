@@ -0,0 +1,37 @@
+package expvarmetrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/tiandh987/errors"
+)
+
+func TestPublish(t *testing.T) {
+	r := errors.NewRegistry()
+	r.MustRegister(errors.NewCoder(230001, 404, "User not found", ""))
+	r.EnableMetrics()
+	_ = r.WithCode(230001, "user")
+
+	Publish("errors_test", r)
+
+	v := expvar.Get("errors_test")
+	if v == nil {
+		t.Fatal("expvar errors_test not published")
+	}
+
+	var metrics []errors.CodeMetric
+	if err := json.Unmarshal([]byte(v.String()), &metrics); err != nil {
+		t.Fatalf("invalid expvar %s: %v", v.String(), err)
+	}
+	if len(metrics) != 1 || metrics[0].Code != 230001 || metrics[0].Created != 1 {
+		t.Errorf("metrics = %+v", metrics)
+	}
+
+	// 发布之后的计数同样可见
+	_ = r.WithCode(230001, "user")
+	if got := Func(r)().([]errors.CodeMetric); got[0].Created != 2 {
+		t.Errorf("Created = %d, want 2", got[0].Created)
+	}
+}
@@ -0,0 +1,242 @@
+package errors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 文件内容：
+//	1、type withFields struct
+//		WithFields() 为错误添加结构化的键值对字段
+//
+//	2、带字段的错误：WithCodeFields()、WrapCFields()（以及 Registry 的同名方法）
+//		与 WithCode、WrapC 相同，创建错误时添加字段，格式化参数原样传给 fmt.Errorf
+//
+//	3、Fields() 合并错误链中所有的字段
+//
+// 字段用于记录用户 ID、租户、资源名称等上下文，不会出现在错误信息中，
+// withCode 使用 %#-v、%#+v 格式化时，每个错误的 JSON 对象包含 fields 成员，
+// 其值为 Fields() 从该错误开始合并的字段，例如：
+//	[{"caller":"#0 ...","code":100101,"error":"user not found","fields":{"tenant":"t1","user_id":42},"message":"Not found"}]
+//
+// 字段与格式化参数分开传递，格式化参数的个数只由格式化字符串决定，go vet 的 printf 检查同样适用。
+//
+// 使用：
+//	err := errors.WithCodeFields(code.ErrUserNotFound, map[string]interface{}{"user_id": id}, "user %s not found", name)
+//	err = errors.WithFields(err, "tenant", tenant)
+//	errors.Fields(err) // map[tenant:t1 user_id:42]
+
+// copyFields 复制 fields，fields 为空时返回 nil
+func copyFields(fields map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	next := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		next[k] = v
+	}
+
+	return next
+}
+
+// newFields 将 kv 转换为字段，kv 依次为键和值，
+// 不是字符串的键使用 fmt.Sprint 转换，最后一个没有值的键的值为 nil
+func newFields(kv []interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+
+		var value interface{}
+		if i+1 < len(kv) {
+			value = kv[i+1]
+		}
+		fields[key] = value
+	}
+
+	return fields
+}
+
+// ===================================================================
+// withFields 为 cause 添加字段，错误信息以及格式化输出与 cause 相同
+type withFields struct {
+	cause  error
+	fields map[string]interface{}
+}
+
+func (w *withFields) Error() string {
+	return w.cause.Error()
+}
+
+func (w *withFields) Cause() error {
+	return w.cause
+}
+
+func (w *withFields) Unwrap() error {
+	return w.cause
+}
+
+// Format 使用相同的格式化指令格式化 cause，
+// cause 是带错误码的错误时，JSON 输出中该错误的字段包含 w 的字段
+func (w *withFields) Format(s fmt.State, verb rune) {
+	cause := w.cause
+	for {
+		v, ok := cause.(*withFields)
+		if !ok {
+			break
+		}
+		cause = v.cause
+	}
+
+	if v, ok := cause.(*withCode); ok {
+		v.renderer().renderedErr(v)
+		v.format(s, verb, w)
+		return
+	}
+
+	fmt.Fprintf(s, directive(s, verb), w.cause)
+}
+
+// directive 还原 s 对应的格式化指令，例如 %#-v
+func directive(s fmt.State, verb rune) string {
+	var b strings.Builder
+	b.WriteByte('%')
+	for _, flag := range "+-# 0" {
+		if s.Flag(int(flag)) {
+			b.WriteRune(flag)
+		}
+	}
+
+	if width, ok := s.Width(); ok {
+		b.WriteString(strconv.Itoa(width))
+	}
+
+	if prec, ok := s.Precision(); ok {
+		b.WriteByte('.')
+		b.WriteString(strconv.Itoa(prec))
+	}
+	b.WriteRune(verb)
+
+	return b.String()
+}
+
+// WithFields 为 err 添加字段，kv 依次为键和值，例如 WithFields(err, "user_id", id, "tenant", tenant)。
+// 不是字符串的键使用 fmt.Sprint 转换，最后一个没有值的键的值为 nil。
+// 如果 err 为 nil，WithFields 返回 nil；kv 为空时返回 err。
+func WithFields(err error, kv ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+
+	if len(kv) == 0 {
+		return err
+	}
+
+	return &withFields{
+		cause:  err,
+		fields: newFields(kv),
+	}
+}
+
+// ownFields 返回 err 自身（不包括 cause）的字段
+func ownFields(err error) map[string]interface{} {
+	switch v := err.(type) {
+	case *withFields:
+		return v.fields
+	case *withCode:
+		return v.fields
+	}
+
+	return nil
+}
+
+// Fields 合并 err 的错误树中所有的字段，外层错误的字段覆盖内层错误的同名字段，
+// 同一层的多个错误（例如聚合错误）中前面错误的字段优先。
+// 错误树中其他包的包装错误（实现了 Unwrap() error 或 Unwrap() []error）也会被展开，没有字段时返回 nil。
+func Fields(err error) map[string]interface{} {
+	var layers []map[string]interface{}
+	walk(err, func(e error) bool {
+		if fields := ownFields(e); len(fields) > 0 {
+			layers = append(layers, fields)
+		}
+		return false
+	})
+
+	if len(layers) == 0 {
+		return nil
+	}
+
+	merged := map[string]interface{}{}
+	for i := len(layers) - 1; i >= 0; i-- {
+		for k, v := range layers[i] {
+			merged[k] = v
+		}
+	}
+
+	return merged
+}
+
+// ===================================================================
+// WithCodeFields 与 WithCode 相同，创建的错误带有 fields 中的字段。
+func WithCodeFields(code int, fields map[string]interface{}, format string, args ...interface{}) error {
+	defaultRegistry.created(code)
+
+	return &withCode{
+		err:    fmt.Errorf(format, args...),
+		code:   code,
+		fields: copyFields(fields),
+		stack:  callers(),
+	}
+}
+
+// WrapCFields 与 WrapC 相同，创建的错误带有 fields 中的字段。
+// 如果 err 为 nil，WrapCFields 返回 nil。
+func WrapCFields(err error, code int, fields map[string]interface{}, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	defaultRegistry.created(code)
+
+	return &withCode{
+		err:    fmt.Errorf(format, args...),
+		code:   code,
+		cause:  err,
+		fields: copyFields(fields),
+		stack:  callers(),
+	}
+}
+
+// WithCodeFields 与 Registry.WithCode 相同，创建的错误带有 fields 中的字段。
+func (r *Registry) WithCodeFields(code int, fields map[string]interface{}, format string, args ...interface{}) error {
+	r.created(code)
+
+	return &withCode{
+		err:      fmt.Errorf(format, args...),
+		code:     code,
+		registry: r,
+		fields:   copyFields(fields),
+		stack:    callers(),
+	}
+}
+
+// WrapCFields 与 Registry.WrapC 相同，创建的错误带有 fields 中的字段。
+// 如果 err 为 nil，WrapCFields 返回 nil。
+func (r *Registry) WrapCFields(err error, code int, fields map[string]interface{}, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	r.created(code)
+
+	return &withCode{
+		err:      fmt.Errorf(format, args...),
+		code:     code,
+		cause:    err,
+		registry: r,
+		fields:   copyFields(fields),
+		stack:    callers(),
+	}
+}
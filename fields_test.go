@@ -0,0 +1,62 @@
+package errors
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithCodeFields(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(250001, 404, "User not found", ""))
+
+	fields := map[string]interface{}{"user_id": 42}
+	err := r.WithCodeFields(250001, fields, "user %d not found", 42)
+	fields["user_id"] = 0
+
+	// 格式化参数原样传给 fmt.Errorf，字段单独记录
+	if got := InternalMessage(err); got != "user 42 not found" {
+		t.Errorf("InternalMessage() = %q", got)
+	}
+	if got := Fields(err); !reflect.DeepEqual(got, map[string]interface{}{"user_id": 42}) {
+		t.Errorf("Fields() = %v, fields not copied", got)
+	}
+	if !r.IsCode(err, 250001) {
+		t.Errorf("IsCode(%v, 250001) = false", err)
+	}
+
+	if got := fmt.Sprintf("%#-v", err); !strings.Contains(got, `"fields":{"user_id":42}`) {
+		t.Errorf("%%#-v = %s, missing fields", got)
+	}
+
+	if got := Fields(WithCodeFields(250001, nil, "user")); got != nil {
+		t.Errorf("Fields() without fields = %v", got)
+	}
+}
+
+func TestWrapCFields(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(250001, 404, "User not found", ""))
+
+	if err := r.WrapCFields(nil, 250001, map[string]interface{}{"user_id": 42}, "get user"); err != nil {
+		t.Errorf("WrapCFields(nil) = %v", err)
+	}
+	if err := WrapCFields(nil, 250001, map[string]interface{}{"user_id": 42}, "get user"); err != nil {
+		t.Errorf("package WrapCFields(nil) = %v", err)
+	}
+
+	cause := WithFields(New("no rows"), "table", "users", "tenant", "t0")
+	err := r.WrapCFields(cause, 250001, map[string]interface{}{"tenant": "t1"}, "get user %w", cause)
+	err = WithFields(err, "request_id", "req-1")
+
+	if !Is(err, cause) || !r.IsCode(err, 250001) {
+		t.Errorf("WrapCFields() = %v, does not wrap the cause with the code", err)
+	}
+
+	// 外层错误的字段覆盖内层错误的同名字段
+	want := map[string]interface{}{"table": "users", "tenant": "t1", "request_id": "req-1"}
+	if got := Fields(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %v, want %v", got, want)
+	}
+}
@@ -10,28 +10,46 @@ type formatInfo struct {
 	code    int
 	message string
 	err     string
-	stack   *stack
+	caller  string // 错误的调用位置，见 callerOf
+	meta    map[string]interface{}
+	fields  map[string]interface{}
 }
 
-// list 会将错误堆栈转换为一个简单的数组。
+// list 会将错误树按深度优先的顺序转换为一个简单的数组，包装多个错误的错误之后依次是每个分支。
 func list(e error) []error {
 	ret := []error{}
 
-	if e != nil {
-		if w, ok := e.(interface{ Unwrap() error }); ok {
-			ret = append(ret, e)
-			ret = append(ret, list(w.Unwrap())...)
-		} else {
-			ret = append(ret, e)
+	walk(e, func(err error) bool {
+		ret = append(ret, err)
+		return false
+	})
+
+	return ret
+}
+
+// callerOf 返回错误的调用位置 file:line (function)：调用栈的第一帧，
+// 没有调用栈时（例如解码的错误）为记录的远程调用栈 frames 的第一帧，都没有时返回空
+func callerOf(st *stack, frames []string) string {
+	if st != nil && len(*st) > 0 {
+		f := Frame((*st)[0])
+		return fmt.Sprintf("%s:%d (%s)", f.file(), f.line(), f.name())
+	}
+
+	if len(frames) > 0 {
+		name, location := splitFrame(frames[0])
+		if location == "" {
+			return name
 		}
+		return fmt.Sprintf("%s (%s)", location, name)
 	}
 
-	return ret
+	return ""
 }
 
 // buildFormatInfo 构建格式化信息
 // 进行类型断言：fundamental、withStack、withCode、其他
-func buildFormatInfo(e error) *formatInfo {
+// withCode 的 Coder 从 snaps 中对应 Registry 的快照中查找。
+func buildFormatInfo(e error, snaps snapshots) *formatInfo {
 	var finfo *formatInfo
 
 	switch err := e.(type) {
@@ -40,22 +58,21 @@ func buildFormatInfo(e error) *formatInfo {
 			code:    unknownCoder.Code(),
 			message: err.msg,
 			err:     err.msg,
-			stack:   err.stack,
+			caller:  callerOf(err.stack, err.frames),
 		}
 	case *withStack:
+		msg := errorText(err)
 		finfo = &formatInfo{
 			code:    unknownCoder.Code(),
-			message: err.Error(),
-			err:     err.Error(),
-			stack:   err.stack,
+			message: msg,
+			err:     msg,
+			caller:  callerOf(err.stack, err.frames),
 		}
 	case *withCode:
-		coder, ok := codes[err.code]
-		if !ok {
-			coder = unknownCoder
-		}
+		state := snaps.of(err.renderer())
+		coder := err.coderIn(state)
 
-		extMsg := coder.String()
+		extMsg := renderMessage(coder, err.params)
 		if extMsg == "" {
 			extMsg = err.err.Error()
 		}
@@ -64,13 +81,19 @@ func buildFormatInfo(e error) *formatInfo {
 			code:    coder.Code(),
 			message: extMsg,
 			err:     err.err.Error(),
-			stack:   err.stack,
+			caller:  callerOf(err.stack, err.frames),
+			meta:    metaFields(coder),
+		}
+		// 远程错误的 Coder 不属于本地的 Registry，没有废弃标记
+		if err.coder == nil {
+			finfo.meta = state.deprecationFields(finfo.meta, err.code)
 		}
 	default:
+		msg := errorText(err)
 		finfo = &formatInfo{
 			code:    unknownCoder.Code(),
-			message: err.Error(),
-			err:     err.Error(),
+			message: msg,
+			err:     msg,
 		}
 	}
 
@@ -90,40 +113,39 @@ func format(k int, jsonData []map[string]interface{}, str *bytes.Buffer, finfo *
 			}
 
 			caller := fmt.Sprintf("#%d", k)
-			if finfo.stack != nil {
-				f := Frame((*finfo.stack)[0])
-				caller = fmt.Sprintf("%s %s:%d (%s)",
-					caller,
-					f.file(),
-					f.line(),
-					f.name(),
-				)
+			if finfo.caller != "" {
+				caller = fmt.Sprintf("%s %s", caller, finfo.caller)
 			}
 			data["caller"] = caller
+
+			for name, v := range finfo.meta {
+				data[name] = v
+			}
+
+			if len(finfo.fields) > 0 {
+				data["fields"] = finfo.fields
+			}
 		} else {
 			data["error"] = finfo.message
 		}
 		jsonData = append(jsonData, data)
 	} else {
 		if flagDetail || flagTrace {
-			if finfo.stack != nil {
-				f := Frame((*finfo.stack)[0])
-				fmt.Fprintf(str, "%s%s - #%d [%s:%d (%s)] (%d) %s",
+			if finfo.caller != "" {
+				fmt.Fprintf(str, "%s%s - #%d [%s] (%d) %s",
 					sep,
 					finfo.err,
 					k,
-					f.file(),
-					f.line(),
-					f.name(),
+					finfo.caller,
 					finfo.code,
 					finfo.message)
 			} else {
 				fmt.Fprintf(str, "%s%s - #%d %s", sep, finfo.err, k, finfo.message)
 			}
 		} else {
-			fmt.Fprintf(str, finfo.message)
+			str.WriteString(finfo.message)
 		}
 	}
 
-	return jsonData, nil
+	return jsonData, str
 }
@@ -0,0 +1,315 @@
+package errors
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// 文件内容：
+//	1、type HandlerFunc func(http.ResponseWriter, *http.Request) error
+//		返回错误的 HTTP 处理函数，错误由 ErrorHandler 写入响应
+//
+//	2、type HeaderCoder interface
+//		可选的 Coder 扩展接口，声明写入错误响应的 HTTP 头，例如 Retry-After、WWW-Authenticate
+//
+//	3、type ErrorHandler struct
+//		(1)Handle()     将 HandlerFunc 转换为 http.Handler
+//		(2)Middleware() 将 panic 恢复为 500 错误
+//		(3)WriteError() 写入错误响应
+//
+// 错误响应的状态码为 ParseCoder(err).HTTPStatus()，响应体为 JSON：
+//	{"code": 100101, "message": "...", "reference": "...", "requestId": "..."}
+// message 是按照 Accept-Language 选择的外部错误文本，
+// 完整的错误链（%+v）只写入服务端日志，不会返回给客户端。
+
+// RequestIDHeader 是携带请求 ID 的 HTTP 头。
+const RequestIDHeader = "X-Request-Id"
+
+// HeaderCoder 是声明了错误响应 HTTP 头的 Coder。
+type HeaderCoder interface {
+	Coder
+
+	// Header 返回写入错误响应的 HTTP 头
+	Header() http.Header
+}
+
+// HandlerFunc 是返回错误的 HTTP 处理函数。
+// 返回的错误由零值 ErrorHandler 写入响应，panic 会被恢复为 500 错误。
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defaultErrorHandler.serve(w, r, f)
+}
+
+// defaultErrorHandler 是 HandlerFunc 使用的零值 ErrorHandler
+var defaultErrorHandler = &ErrorHandler{}
+
+// httpError 是错误响应体
+type httpError struct {
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	Reference string `json:"reference,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// ===================================================================
+// ErrorHandler 将错误写入 HTTP 响应，零值可以直接使用。
+type ErrorHandler struct {
+	// Registry 用于解析错误码，为 nil 时使用渲染错误的 Registry
+	Registry *Registry
+
+	// PanicCode 是 panic 恢复后使用的错误码，为 0 时使用 unknown Coder
+	PanicCode int
+
+	// Logf 记录错误链，为 nil 时使用 log.Printf
+	Logf func(format string, args ...interface{})
+
+	// RequestID 返回请求 ID，为 nil 时使用请求的 X-Request-Id 头，没有时随机生成
+	RequestID func(r *http.Request) string
+}
+
+// Handle 将 f 转换为 http.Handler，f 返回的错误使用 WriteError 写入响应，panic 会被恢复为 500 错误。
+func (h *ErrorHandler) Handle(f HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.serve(w, r, f)
+	})
+}
+
+// Middleware 恢复 next 中的 panic，记录 panic 时的调用栈，并使用 PanicCode 写入错误响应。
+// 与 net/http 相同，http.ErrAbortHandler 会被重新 panic。
+// 如果 panic 之前已经写入了响应头，只记录错误。
+func (h *ErrorHandler) Middleware(next http.Handler) http.Handler {
+	f := func(w http.ResponseWriter, r *http.Request) error {
+		next.ServeHTTP(w, r)
+		return nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.serve(w, r, f)
+	})
+}
+
+// serve 调用 f，将 f 返回的错误写入响应，并恢复 f 中的 panic。
+// f 已经写入了响应头或者响应体时，再写入错误响应会产生 superfluous WriteHeader 并破坏响应体，只记录错误。
+func (h *ErrorHandler) serve(w http.ResponseWriter, r *http.Request, f HandlerFunc) {
+	rw := &responseWriter{ResponseWriter: w}
+	defer h.recoverPanic(w, r, rw)
+
+	err := f(rw, r)
+	if err == nil {
+		return
+	}
+
+	if rw.wroteHeader {
+		h.logf(r, h.requestID(r), err)
+		return
+	}
+
+	h.WriteError(rw, r, err)
+}
+
+// recoverPanic 恢复 panic 并写入错误响应，只能由 defer 直接调用
+func (h *ErrorHandler) recoverPanic(w http.ResponseWriter, r *http.Request, rw *responseWriter) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	// panic 不是业务代码创建的错误，不增加错误码的创建次数
+	err := &withCode{
+		err:      fmt.Errorf("panic: %v", v),
+		code:     h.PanicCode,
+		registry: h.registry(nil),
+		stack:    panicStack(),
+	}
+	requestID := h.requestID(r)
+	h.logger()("%s %s (request %s): panic: %v\n%s", r.Method, r.URL.RequestURI(), requestID, v, debug.Stack())
+
+	if rw.wroteHeader {
+		h.logf(r, requestID, err)
+		return
+	}
+
+	h.writeError(w, r, requestID, err)
+}
+
+// panicStack 返回 panic 发生处的调用栈，只能由 recoverPanic 直接调用。
+// 在 defer 中获取的调用栈从 runtime.gopanic 开始，跳过 runtime.gopanic 以及运行时引发 panic 的帧
+// （例如 runtime.panicIndex、runtime.sigpanic），第一帧为引发 panic 的函数。
+func panicStack() *stack {
+	const depth = 64
+	var pcs [depth]uintptr
+	n := runtime.Callers(3, pcs[:])
+
+	st := stack(pcs[:n])
+	for i, pc := range st {
+		if Frame(pc).name() != "runtime.gopanic" {
+			continue
+		}
+
+		st = st[i+1:]
+		for len(st) > 0 && strings.HasPrefix(Frame(st[0]).name(), "runtime.") {
+			st = st[1:]
+		}
+		break
+	}
+
+	return &st
+}
+
+// WriteError 将 err 写入响应，并在服务端记录完整的错误链。
+func (h *ErrorHandler) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	h.writeError(w, r, h.requestID(r), err)
+}
+
+func (h *ErrorHandler) writeError(w http.ResponseWriter, r *http.Request, requestID string, err error) {
+	reg := h.registry(err)
+	coder := reg.ParseCoder(err)
+
+	h.logf(r, requestID, err)
+
+	header := w.Header()
+	if hc, ok := coder.(HeaderCoder); ok {
+		for key, values := range hc.Header() {
+			header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+	}
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set(RequestIDHeader, requestID)
+	w.WriteHeader(coder.HTTPStatus())
+
+	_ = json.NewEncoder(w).Encode(httpError{
+		Code:      coder.Code(),
+		Message:   reg.LocalizeAccept(err, r.Header.Get("Accept-Language")),
+		Reference: coder.Reference(),
+		RequestID: requestID,
+	})
+}
+
+func (h *ErrorHandler) registry(err error) *Registry {
+	if h.Registry != nil {
+		return h.Registry
+	}
+
+	return registryOf(err)
+}
+
+func (h *ErrorHandler) logger() func(format string, args ...interface{}) {
+	if h.Logf != nil {
+		return h.Logf
+	}
+
+	return log.Printf
+}
+
+// logf 记录 err 的完整错误链
+func (h *ErrorHandler) logf(r *http.Request, requestID string, err error) {
+	h.logger()("%s %s (request %s): %+v", r.Method, r.URL.RequestURI(), requestID, err)
+}
+
+func (h *ErrorHandler) requestID(r *http.Request) string {
+	if h.RequestID != nil {
+		return h.RequestID(r)
+	}
+
+	if id := r.Header.Get(RequestIDHeader); id != "" {
+		return id
+	}
+
+	return newRequestID()
+}
+
+// newRequestID 随机生成 16 字节的请求 ID
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b[:])
+}
+
+// responseWriter 记录是否已经写入了响应头。
+// http.Flusher、http.Hijacker、http.Pusher、io.ReaderFrom 转发给被包装的 ResponseWriter，
+// 被包装的 ResponseWriter 不支持时 Hijack、Push 返回 http.ErrNotSupported；
+// Unwrap 返回被包装的 ResponseWriter，用于 http.ResponseController 访问其他可选接口。
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush 实现 http.Flusher
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack 实现 http.Hijacker
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		w.wroteHeader = true
+	}
+
+	return conn, rw, err
+}
+
+// Push 实现 http.Pusher
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+
+	return http.ErrNotSupported
+}
+
+// ReadFrom 实现 io.ReaderFrom，被包装的 ResponseWriter 支持时可以使用 sendfile 等优化
+func (w *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.wroteHeader = true
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(src)
+	}
+
+	return io.Copy(writerOnly{w.ResponseWriter}, src)
+}
+
+// Unwrap 返回被包装的 ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writerOnly 隐藏 ResponseWriter 的 ReadFrom，避免 io.Copy 递归调用
+type writerOnly struct {
+	io.Writer
+}
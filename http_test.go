@@ -0,0 +1,264 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newTestHandler 返回使用 r 的 ErrorHandler，日志记录在 logs 中
+func newTestHandler(r *Registry, logs *[]string) *ErrorHandler {
+	return &ErrorHandler{
+		Registry:  r,
+		PanicCode: 150002,
+		Logf: func(format string, args ...interface{}) {
+			*logs = append(*logs, fmt.Sprintf(format, args...))
+		},
+	}
+}
+
+func newHTTPTestRegistry() *Registry {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(150001, 404, "User not found", "https://example.com/errors/150001"))
+	r.MustRegister(NewCoder(150002, 500, "Internal server error", ""))
+
+	return r
+}
+
+func TestErrorHandlerWriteError(t *testing.T) {
+	r := newHTTPTestRegistry()
+	var logs []string
+	h := newTestHandler(r, &logs)
+
+	handler := h.Handle(func(w http.ResponseWriter, req *http.Request) error {
+		return r.WrapC(New("no rows"), 150001, "get user %s", "alice")
+	})
+
+	req := httptest.NewRequest("GET", "/users/alice", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != 404 {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+	if got := rec.Header().Get(RequestIDHeader); got != "req-1" {
+		t.Errorf("%s = %q", RequestIDHeader, got)
+	}
+
+	var body httpError
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", rec.Body.String(), err)
+	}
+	want := httpError{Code: 150001, Message: "User not found", Reference: "https://example.com/errors/150001", RequestID: "req-1"}
+	if body != want {
+		t.Errorf("body = %+v, want %+v", body, want)
+	}
+
+	// 完整的错误链只写入日志
+	if strings.Contains(rec.Body.String(), "no rows") {
+		t.Errorf("body leaks the error chain: %s", rec.Body.String())
+	}
+	if len(logs) != 1 || !strings.Contains(logs[0], "no rows") {
+		t.Errorf("logs = %q", logs)
+	}
+}
+
+func TestErrorHandlerPanic(t *testing.T) {
+	r := newHTTPTestRegistry()
+	r.EnableMetrics()
+	var logs []string
+	h := newTestHandler(r, &logs)
+
+	handler := h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != 500 {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"code":150002`) {
+		t.Errorf("body = %s", rec.Body.String())
+	}
+	if len(logs) == 0 || !strings.Contains(logs[0], "panic: boom") {
+		t.Errorf("logs = %q", logs)
+	}
+
+	// panic 不是业务代码创建的错误
+	for _, m := range r.Metrics() {
+		if m.Created != 0 {
+			t.Errorf("panic counted %d created errors for %d", m.Created, m.Code)
+		}
+	}
+}
+
+func TestErrorHandlerPanicAfterWrite(t *testing.T) {
+	r := newHTTPTestRegistry()
+	var logs []string
+	h := newTestHandler(r, &logs)
+
+	handler := h.Handle(func(w http.ResponseWriter, req *http.Request) error {
+		w.WriteHeader(202)
+		panic("late")
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != 202 || rec.Body.Len() != 0 {
+		t.Errorf("response = %d %q, want the status written before the panic", rec.Code, rec.Body.String())
+	}
+	if len(logs) != 2 {
+		t.Errorf("logs = %q, want the panic and the error chain", logs)
+	}
+}
+
+func TestErrorHandlerErrorAfterWrite(t *testing.T) {
+	r := newHTTPTestRegistry()
+	var logs []string
+	h := newTestHandler(r, &logs)
+
+	handler := h.Handle(func(w http.ResponseWriter, req *http.Request) error {
+		fmt.Fprint(w, "partial")
+		return r.WithCode(150001, "user alice")
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	// 已经写入的响应不会被错误响应破坏，错误只记录在日志中
+	if rec.Code != 200 || rec.Body.String() != "partial" {
+		t.Errorf("response = %d %q, want the response written by the handler", rec.Code, rec.Body.String())
+	}
+	if len(logs) != 1 || !strings.Contains(logs[0], "user alice") {
+		t.Errorf("logs = %q, want the error chain", logs)
+	}
+}
+
+func TestErrorHandlerPanicStack(t *testing.T) {
+	tests := []struct {
+		name string
+		f    http.HandlerFunc
+	}{
+		{"panic", func(w http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		}},
+		{"runtime error", func(w http.ResponseWriter, req *http.Request) {
+			var s []int
+			_ = s[len(req.URL.Path)]
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs []string
+			h := newTestHandler(newHTTPTestRegistry(), &logs)
+			h.Middleware(tt.f).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+			// 调用栈从引发 panic 的函数开始，而不是 runtime.gopanic
+			if len(logs) != 2 {
+				t.Fatalf("logs = %q", logs)
+			}
+			if !strings.Contains(logs[1], "http_test.go") || strings.Contains(logs[1], "runtime.") {
+				t.Errorf("error = %s, want the caller to be the panicking handler", logs[1])
+			}
+		})
+	}
+}
+
+func TestErrorHandlerAbortHandler(t *testing.T) {
+	h := newTestHandler(newHTTPTestRegistry(), new([]string))
+	handler := h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("recover() = %v, want http.ErrAbortHandler", v)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestHandlerFunc(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	r := newHTTPTestRegistry()
+	handler := HandlerFunc(func(w http.ResponseWriter, req *http.Request) error {
+		return r.WithCode(150001, "missing")
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	// 零值 ErrorHandler 使用渲染错误的 Registry
+	if rec.Code != 404 || !strings.Contains(rec.Body.String(), `"code":150001`) {
+		t.Errorf("response = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get(RequestIDHeader) == "" {
+		t.Error("no request ID generated")
+	}
+}
+
+func TestResponseWriterForwarding(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := &responseWriter{ResponseWriter: rec}
+
+	if rw.Unwrap() != rec {
+		t.Error("Unwrap() did not return the wrapped ResponseWriter")
+	}
+
+	rw.Flush()
+	if !rec.Flushed || !rw.wroteHeader {
+		t.Error("Flush() was not forwarded")
+	}
+
+	// httptest.ResponseRecorder 不支持 Hijack、Push
+	if _, _, err := rw.Hijack(); err != http.ErrNotSupported {
+		t.Errorf("Hijack() = %v, want http.ErrNotSupported", err)
+	}
+	if err := rw.Push("/style.css", nil); err != http.ErrNotSupported {
+		t.Errorf("Push() = %v, want http.ErrNotSupported", err)
+	}
+
+	n, err := rw.ReadFrom(strings.NewReader("hello"))
+	if err != nil || n != 5 || rec.Body.String() != "hello" {
+		t.Errorf("ReadFrom() = %d, %v, body %q", n, err, rec.Body.String())
+	}
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	h := newTestHandler(newHTTPTestRegistry(), new([]string))
+	server := httptest.NewServer(h.Handle(func(w http.ResponseWriter, req *http.Request) error {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		return buf.Flush()
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if string(body) != "hijacked" {
+		t.Errorf("body = %q, want hijacked", body)
+	}
+}
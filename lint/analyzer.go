@@ -0,0 +1,124 @@
+// Package lint 提供检查错误码使用规范的静态分析。
+//
+// 分析器的接口参照 golang.org/x/tools/go/analysis 设计（Analyzer、Pass、Diagnostic），
+// 但只依赖标准库，可以直接通过 cmd/errlint 运行：
+//
+//	codes    检查未注册的错误码以及重复注册的错误码
+//	printf   检查 WithCode、WrapC、Wrapf、Errorf 等函数的格式化字符串
+//	uncoded  检查导出函数直接返回不带错误码的错误
+package lint
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"sync"
+)
+
+// ErrorsPath 是 errors 包的导入路径。
+const ErrorsPath = "github.com/tiandh987/errors"
+
+// Analyzer 描述一个静态分析。
+type Analyzer struct {
+	// Name 分析器名称，用于命令行参数以及诊断信息
+	Name string
+
+	// Doc 分析器说明
+	Doc string
+
+	// Flags 分析器的命令行参数
+	Flags flag.FlagSet
+
+	// Run 对一个包执行分析，通过 Pass.Reportf 报告问题
+	Run func(*Pass) error
+}
+
+// Diagnostic 是分析器报告的一个问题。
+type Diagnostic struct {
+	Pos      token.Position
+	Analyzer string
+	Message  string
+}
+
+// String 返回与 go vet 相同的 `file:line:col: message` 形式的诊断信息。
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Pos, d.Message)
+}
+
+// Pass 是一个分析器对一个包执行分析时的上下文。
+type Pass struct {
+	Analyzer  *Analyzer
+	Fset      *token.FileSet
+	Files     []*ast.File
+	Pkg       *types.Package
+	TypesInfo *types.Info
+
+	// Program 包含本次分析加载的所有包，用于需要全局信息的分析，例如错误码注册情况
+	Program *Program
+
+	report func(Diagnostic)
+}
+
+// Reportf 报告 pos 处的问题。
+func (pass *Pass) Reportf(pos token.Pos, format string, args ...interface{}) {
+	pass.report(Diagnostic{
+		Pos:      pass.Fset.Position(pos),
+		Analyzer: pass.Analyzer.Name,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Analyzers 返回所有的分析器。
+func Analyzers() []*Analyzer {
+	return []*Analyzer{
+		CodesAnalyzer,
+		PrintfAnalyzer,
+		UncodedAnalyzer,
+	}
+}
+
+// Run 对 prog 中的所有包执行 analyzers，返回按照位置排序的诊断信息。
+func Run(prog *Program, analyzers ...*Analyzer) ([]Diagnostic, error) {
+	var (
+		mux   sync.Mutex
+		diags []Diagnostic
+	)
+
+	for _, pkg := range prog.Packages {
+		for _, a := range analyzers {
+			pass := &Pass{
+				Analyzer:  a,
+				Fset:      prog.Fset,
+				Files:     pkg.Files,
+				Pkg:       pkg.Types,
+				TypesInfo: pkg.TypesInfo,
+				Program:   prog,
+				report: func(d Diagnostic) {
+					mux.Lock()
+					diags = append(diags, d)
+					mux.Unlock()
+				},
+			}
+
+			if err := a.Run(pass); err != nil {
+				return nil, fmt.Errorf("%s: %s: %v", a.Name, pkg.Path, err)
+			}
+		}
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Pos, diags[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return diags, nil
+}
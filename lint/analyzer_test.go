@@ -0,0 +1,108 @@
+package lint
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// runFixture 参照 golang.org/x/tools/go/analysis/analysistest 使用 testdata/src 中的包测试分析器：
+// 包中的 `// want "regexp"` 注释声明该行应该报告的问题，
+// 每个问题都必须与同一行的一个 want 匹配，每个 want 也必须被一个问题匹配。
+func runFixture(t *testing.T, a *Analyzer, pkg string) {
+	t.Helper()
+
+	prog, err := Load(filepath.Join("testdata", "src"), "./"+pkg)
+	if err != nil {
+		t.Fatalf("load %s: %v", pkg, err)
+	}
+
+	wants := map[string][]*regexp.Regexp{}
+	for _, p := range prog.Packages {
+		for _, f := range p.Files {
+			for _, group := range f.Comments {
+				for _, c := range group.List {
+					patterns, err := parseWant(c.Text)
+					if err != nil {
+						t.Fatalf("%s: %v", prog.Fset.Position(c.Pos()), err)
+					}
+
+					pos := prog.Fset.Position(c.Pos())
+					key := fmt.Sprintf("%s:%d", pos.Filename, pos.Line)
+					wants[key] = append(wants[key], patterns...)
+				}
+			}
+		}
+	}
+
+	diags, err := Run(prog, a)
+	if err != nil {
+		t.Fatalf("run %s: %v", a.Name, err)
+	}
+
+	for _, d := range diags {
+		key := fmt.Sprintf("%s:%d", d.Pos.Filename, d.Pos.Line)
+
+		matched := false
+		for i, re := range wants[key] {
+			if re.MatchString(d.Message) {
+				wants[key] = append(wants[key][:i], wants[key][i+1:]...)
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			t.Errorf("%s: unexpected diagnostic: %s", d.Pos, d.Message)
+		}
+	}
+
+	for key, res := range wants {
+		for _, re := range res {
+			t.Errorf("%s: no diagnostic matching %q", key, re)
+		}
+	}
+}
+
+// parseWant 解析 `// want "regexp" ...` 注释中的正则表达式，不是 want 注释时返回 nil
+func parseWant(comment string) ([]*regexp.Regexp, error) {
+	text := strings.TrimSpace(strings.TrimPrefix(comment, "//"))
+	if !strings.HasPrefix(text, "want ") {
+		return nil, nil
+	}
+	text = strings.TrimSpace(strings.TrimPrefix(text, "want "))
+
+	var res []*regexp.Regexp
+	for text != "" {
+		quoted, err := strconv.QuotedPrefix(text)
+		if err != nil {
+			return nil, fmt.Errorf("malformed want comment %q: %v", comment, err)
+		}
+
+		pattern, _ := strconv.Unquote(quoted)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+
+		text = strings.TrimSpace(text[len(quoted):])
+	}
+
+	return res, nil
+}
+
+func TestCodes(t *testing.T) {
+	runFixture(t, CodesAnalyzer, "codes")
+}
+
+func TestPrintf(t *testing.T) {
+	runFixture(t, PrintfAnalyzer, "printf")
+}
+
+func TestUncoded(t *testing.T) {
+	runFixture(t, UncodedAnalyzer, "uncoded")
+}
@@ -0,0 +1,76 @@
+package lint
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+)
+
+// callee 返回 call 调用的函数或方法，无法静态确定时返回 nil。
+func callee(info *types.Info, call *ast.CallExpr) *types.Func {
+	var id *ast.Ident
+	switch fun := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return nil
+	}
+
+	fn, _ := info.Uses[id].(*types.Func)
+	return fn
+}
+
+// unparen 去掉 expr 外层的括号
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		p, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = p.X
+	}
+}
+
+// errorsFunc 报告 fn 是否为 errors 包中名为 name 的函数，或者 errors.Registry 的同名方法。
+func errorsFunc(fn *types.Func, names ...string) bool {
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != ErrorsPath {
+		return false
+	}
+
+	for _, name := range names {
+		if fn.Name() == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// intConst 返回 expr 的整数常量值。
+func intConst(info *types.Info, expr ast.Expr) (int64, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return 0, false
+	}
+
+	return constant.Int64Val(tv.Value)
+}
+
+// stringConst 返回 expr 的字符串常量值。
+func stringConst(info *types.Info, expr ast.Expr) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+
+	return constant.StringVal(tv.Value), true
+}
+
+// inspect 遍历 pass 中的所有文件。
+func inspect(pass *Pass, fn func(ast.Node) bool) {
+	for _, f := range pass.Files {
+		ast.Inspect(f, fn)
+	}
+}
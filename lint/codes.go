@@ -0,0 +1,409 @@
+package lint
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/tiandh987/errors"
+)
+
+// CodesAnalyzer 检查未注册的错误码以及重复注册的错误码。
+//
+// 错误码的注册从以下调用中识别，注册的 Coder 可以是 errors.NewCoder、errors.NewTemplateCoder 的调用，
+// 实现了 errors.Coder 的结构体字面量（错误码取自字段 C 或 Code，未指定字段名时取第一个字段），
+// 或者使用它们初始化的变量：
+//
+//	errors.Register、errors.MustRegister、errors.TryRegister 以及 Registry、Namespace 的同名方法（每个参数都是一次注册）
+//	errors.RegisterAlias、errors.MustRegisterAlias 以及 Registry 的同名方法，注册的是别名
+//
+// 包级别函数注册到默认 Registry，方法调用注册到接收者引用的变量（或字段）对应的 Registry，
+// 只有同一个 Registry 中的重复注册才会被报告。
+// 注册的错误码不是常量（例如在循环中注册）以及通过 RegisterCatalog、LoadCatalog、LoadCatalogFile
+// 注册的 Registry 无法静态确定注册了哪些错误码，使用它们的调用不会被报告为未注册。
+//
+// 使用错误码的调用包括 WithCode、WrapC、WithCodeParams、WrapCParams、IsCode 以及 Registry 的同名方法，
+// 只有常量错误码会被检查，错误码在任意一个 Registry 中注册过即可。
+// 通过目录文件注册到默认 Registry 的错误码可以使用 -catalog 参数指定。
+var CodesAnalyzer = &Analyzer{
+	Name: "codes",
+	Doc:  "check for unregistered and duplicate error codes",
+	Run:  runCodes,
+}
+
+var catalogs string
+
+func init() {
+	CodesAnalyzer.Flags.StringVar(&catalogs, "catalog", "", "comma-separated list of catalog files whose codes are registered in the default registry at runtime")
+}
+
+// defaultRegistry 是默认 Registry 的 key，见 registryKey
+const defaultRegistry = ""
+
+// registration 是一次错误码注册
+type registration struct {
+	code     int
+	registry string // 注册的 Registry，见 registryKey
+	pos      token.Pos
+	dynamic  bool // 无法静态确定错误码，code 无意义
+}
+
+// registered 是一个 Registry 中的错误码
+type registered struct {
+	registry string
+	code     int
+}
+
+// registrations 是整个程序中的错误码注册情况
+type registrations struct {
+	first   map[registered]token.Pos // 每个 Registry 中每个错误码第一次注册的位置，目录文件注册的错误码为 token.NoPos
+	codes   map[int]bool             // 在任意一个 Registry 中注册过的错误码
+	dynamic map[string]bool          // 无法静态确定注册了哪些错误码的 Registry
+	catalog map[int]bool
+	err     error
+}
+
+func runCodes(pass *Pass) error {
+	regs := pass.Program.Cache("codes", func() interface{} {
+		return collectRegistrations(pass.Program)
+	}).(*registrations)
+	if regs.err != nil {
+		return regs.err
+	}
+
+	// 重复注册
+	for _, reg := range findRegistrations(pass.Program, pass.Pkg, pass.TypesInfo, pass.Files) {
+		if reg.dynamic {
+			continue
+		}
+
+		if reg.registry == defaultRegistry && regs.catalog[reg.code] {
+			pass.Reportf(reg.pos, "code %d is already registered by catalog", reg.code)
+			continue
+		}
+
+		if first := regs.first[registered{reg.registry, reg.code}]; first != reg.pos {
+			pass.Reportf(reg.pos, "code %d is already registered in the same registry at %s", reg.code, pass.Fset.Position(first))
+		}
+	}
+
+	// 未注册的错误码
+	inspect(pass, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		fn := callee(pass.TypesInfo, call)
+		var arg int
+		switch {
+		case errorsFunc(fn, "WithCode", "WithCodeParams"):
+			arg = 0
+		case errorsFunc(fn, "WrapC", "WrapCParams", "IsCode"):
+			arg = 1
+		default:
+			return true
+		}
+
+		if len(call.Args) <= arg {
+			return true
+		}
+
+		code, ok := intConst(pass.TypesInfo, call.Args[arg])
+		if !ok || code == 0 {
+			return true
+		}
+
+		if !regs.codes[int(code)] && !regs.dynamic[registryKey(pass.Program, pass.TypesInfo, call)] {
+			pass.Reportf(call.Args[arg].Pos(), "code %d passed to %s is not registered", code, fn.Name())
+		}
+
+		return true
+	})
+
+	return nil
+}
+
+// collectRegistrations 收集程序中所有包以及目录文件中的错误码注册
+func collectRegistrations(prog *Program) *registrations {
+	regs := &registrations{
+		first:   map[registered]token.Pos{},
+		codes:   map[int]bool{},
+		dynamic: map[string]bool{},
+		catalog: map[int]bool{},
+	}
+
+	for _, path := range strings.Split(catalogs, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+
+		r := errors.NewRegistry()
+		if err := r.LoadCatalogFile(path); err != nil {
+			regs.err = err
+			return regs
+		}
+
+		for _, e := range r.Catalog() {
+			for _, code := range append([]int{e.Code}, e.Aliases...) {
+				regs.first[registered{defaultRegistry, code}] = token.NoPos
+				regs.codes[code] = true
+				regs.catalog[code] = true
+			}
+		}
+	}
+
+	var all []registration
+	for _, pkg := range prog.Packages {
+		all = append(all, findRegistrations(prog, pkg.Types, pkg.TypesInfo, pkg.Files)...)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := prog.Fset.Position(all[i].pos), prog.Fset.Position(all[j].pos)
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+
+	for _, reg := range all {
+		if reg.dynamic {
+			regs.dynamic[reg.registry] = true
+			continue
+		}
+
+		regs.codes[reg.code] = true
+		key := registered{reg.registry, reg.code}
+		if _, ok := regs.first[key]; !ok {
+			regs.first[key] = reg.pos
+		}
+	}
+
+	return regs
+}
+
+// findRegistrations 返回包中所有的错误码注册
+func findRegistrations(prog *Program, pkg *types.Package, info *types.Info, files []*ast.File) []registration {
+	c := &coderCodes{
+		coder: coderInterface(pkg),
+		info:  info,
+		inits: initializers(info, files),
+	}
+
+	var regs []registration
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+
+			add := func(code int64, pos token.Pos, ok bool) {
+				regs = append(regs, registration{code: int(code), registry: registryKey(prog, info, call), pos: pos, dynamic: !ok})
+			}
+
+			switch fn := callee(info, call); {
+			case errorsFunc(fn, "Register", "MustRegister", "TryRegister"):
+				// MustRegister、TryRegister 可以一次注册多个 Coder，展开切片的调用视为动态注册
+				if call.Ellipsis.IsValid() {
+					add(0, call.Pos(), false)
+					break
+				}
+				for _, arg := range call.Args {
+					code, ok := c.code(arg, 0)
+					add(code, arg.Pos(), ok)
+				}
+			case errorsFunc(fn, "RegisterAlias", "MustRegisterAlias"):
+				code, ok := intConst(info, call.Args[0])
+				add(code, call.Pos(), ok)
+			case errorsFunc(fn, "RegisterCatalog", "LoadCatalog", "LoadCatalogFile"):
+				add(0, call.Pos(), false)
+			}
+
+			return true
+		})
+	}
+
+	return regs
+}
+
+// registryKey 返回 call 注册或使用错误码的 Registry 的 key：
+// 包级别函数以及 errors.DefaultRegistry() 的方法为默认 Registry，
+// 其他方法调用为接收者引用的变量或字段，无法确定时为接收者表达式的文本
+func registryKey(prog *Program, info *types.Info, call *ast.CallExpr) string {
+	fn := callee(info, call)
+	if sig, ok := fn.Type().(*types.Signature); !ok || sig.Recv() == nil {
+		return defaultRegistry
+	}
+
+	sel, ok := unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return defaultRegistry
+	}
+
+	var id *ast.Ident
+	switch x := unparen(sel.X).(type) {
+	case *ast.CallExpr:
+		if errorsFunc(callee(info, x), "DefaultRegistry") {
+			return defaultRegistry
+		}
+	case *ast.Ident:
+		id = x
+	case *ast.SelectorExpr:
+		id = x.Sel
+	}
+
+	if id != nil {
+		if obj, ok := info.Uses[id].(*types.Var); ok {
+			return objectKey(prog, obj)
+		}
+	}
+
+	return types.ExprString(sel.X)
+}
+
+// objectKey 返回变量的 key，包级别变量为 包路径.名称，其他变量为声明的位置
+func objectKey(prog *Program, obj *types.Var) string {
+	if obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope() {
+		return obj.Pkg().Path() + "." + obj.Name()
+	}
+
+	return prog.Fset.Position(obj.Pos()).String()
+}
+
+// initializers 返回包中使用单个表达式初始化的变量及其初始值
+func initializers(info *types.Info, files []*ast.File) map[types.Object]ast.Expr {
+	inits := map[types.Object]ast.Expr{}
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ValueSpec:
+				if len(n.Names) == len(n.Values) {
+					for i, name := range n.Names {
+						if obj := info.Defs[name]; obj != nil {
+							inits[obj] = n.Values[i]
+						}
+					}
+				}
+			case *ast.AssignStmt:
+				if n.Tok == token.DEFINE && len(n.Lhs) == len(n.Rhs) {
+					for i, lhs := range n.Lhs {
+						if id, ok := lhs.(*ast.Ident); ok && info.Defs[id] != nil {
+							inits[info.Defs[id]] = n.Rhs[i]
+						}
+					}
+				}
+			}
+
+			return true
+		})
+	}
+
+	return inits
+}
+
+// coderCodes 从 Coder 表达式中取出错误码
+type coderCodes struct {
+	coder *types.Interface
+	info  *types.Info
+	inits map[types.Object]ast.Expr
+}
+
+// maxDepth 是通过变量初始值查找错误码的最大深度
+const maxDepth = 8
+
+// code 返回 Coder 表达式 expr 的错误码，无法静态确定时 ok 为 false
+func (c *coderCodes) code(expr ast.Expr, depth int) (int64, bool) {
+	if depth > maxDepth {
+		return 0, false
+	}
+
+	expr = unparen(expr)
+	if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		expr = unparen(u.X)
+	}
+
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		if errorsFunc(callee(c.info, e), "NewCoder", "NewTemplateCoder") && len(e.Args) > 0 {
+			return intConst(c.info, e.Args[0])
+		}
+	case *ast.CompositeLit:
+		return c.literalCode(e, depth)
+	case *ast.Ident:
+		if init, ok := c.inits[c.info.Uses[e]]; ok {
+			return c.code(init, depth+1)
+		}
+	}
+
+	return 0, false
+}
+
+// literalCode 返回实现了 Coder 的结构体字面量中的错误码，
+// 第一个字段（或名为 Coder 的嵌入字段）也可以是包装的 Coder
+func (c *coderCodes) literalCode(lit *ast.CompositeLit, depth int) (int64, bool) {
+	t := c.info.TypeOf(lit)
+	if t == nil || c.coder == nil || !(types.Implements(t, c.coder) || types.Implements(types.NewPointer(t), c.coder)) {
+		return 0, false
+	}
+
+	if _, ok := t.Underlying().(*types.Struct); !ok || len(lit.Elts) == 0 {
+		return 0, false
+	}
+
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			if code, ok := intConst(c.info, elt); ok {
+				return code, true
+			}
+			return c.code(elt, depth+1)
+		}
+
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+
+		switch key.Name {
+		case "C", "Code":
+			return intConst(c.info, kv.Value)
+		case "Coder":
+			return c.code(kv.Value, depth+1)
+		}
+	}
+
+	return 0, false
+}
+
+// coderInterface 返回 pkg 可见的 errors.Coder 接口类型
+func coderInterface(pkg *types.Package) *types.Interface {
+	if pkg == nil {
+		return nil
+	}
+
+	scope := pkg.Scope()
+	if pkg.Path() != ErrorsPath {
+		scope = nil
+		for _, imp := range pkg.Imports() {
+			if imp.Path() == ErrorsPath {
+				scope = imp.Scope()
+				break
+			}
+		}
+	}
+
+	if scope == nil {
+		return nil
+	}
+
+	obj, ok := scope.Lookup("Coder").(*types.TypeName)
+	if !ok {
+		return nil
+	}
+
+	iface, _ := obj.Type().Underlying().(*types.Interface)
+	return iface
+}
@@ -0,0 +1,171 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// Package 是一个经过语法解析和类型检查的包。
+type Package struct {
+	Path      string
+	Name      string
+	Files     []*ast.File
+	Types     *types.Package
+	TypesInfo *types.Info
+}
+
+// Program 包含一次分析加载的所有包。
+type Program struct {
+	Fset     *token.FileSet
+	Packages []*Package
+
+	mux   sync.Mutex
+	cache map[string]interface{}
+}
+
+// Cache 返回 key 对应的全局分析结果，第一次调用时使用 fn 计算。
+func (prog *Program) Cache(key string, fn func() interface{}) interface{} {
+	prog.mux.Lock()
+	defer prog.mux.Unlock()
+
+	if v, ok := prog.cache[key]; ok {
+		return v
+	}
+
+	v := fn()
+	prog.cache[key] = v
+	return v
+}
+
+// listedPackage 是 `go list -json` 输出的一个包。
+type listedPackage struct {
+	ImportPath string
+	Name       string
+	Dir        string
+	GoFiles    []string
+	Export     string
+	DepOnly    bool
+	ImportMap  map[string]string
+	Error      *struct {
+		Err string
+	}
+}
+
+// Load 使用 `go list` 加载 patterns 匹配的包，
+// 依赖包通过编译器导出数据进行类型检查，匹配的包从源码解析。
+func Load(dir string, patterns ...string) (*Program, error) {
+	args := append([]string{"list", "-e", "-export", "-deps", "-json"}, patterns...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %v", err)
+	}
+
+	var (
+		exports = map[string]string{}
+		targets []*listedPackage
+	)
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		lp := new(listedPackage)
+		if err := dec.Decode(lp); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("go list: %v", err)
+		}
+
+		if lp.Export != "" {
+			exports[lp.ImportPath] = lp.Export
+		}
+
+		if !lp.DepOnly {
+			if lp.Error != nil {
+				return nil, fmt.Errorf("%s: %s", lp.ImportPath, lp.Error.Err)
+			}
+			targets = append(targets, lp)
+		}
+	}
+
+	prog := &Program{
+		Fset:  token.NewFileSet(),
+		cache: map[string]interface{}{},
+	}
+
+	gc := importer.ForCompiler(prog.Fset, "gc", func(path string) (io.ReadCloser, error) {
+		file, ok := exports[path]
+		if !ok {
+			return nil, fmt.Errorf("no export data for %q", path)
+		}
+		return os.Open(file)
+	})
+
+	for _, lp := range targets {
+		pkg, err := check(prog.Fset, lp, gc)
+		if err != nil {
+			return nil, err
+		}
+		prog.Packages = append(prog.Packages, pkg)
+	}
+
+	return prog, nil
+}
+
+// importerFunc 将函数适配为 types.Importer
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+// check 解析并类型检查一个包
+func check(fset *token.FileSet, lp *listedPackage, gc types.Importer) (*Package, error) {
+	pkg := &Package{
+		Path: lp.ImportPath,
+		Name: lp.Name,
+		TypesInfo: &types.Info{
+			Types:      map[ast.Expr]types.TypeAndValue{},
+			Defs:       map[*ast.Ident]types.Object{},
+			Uses:       map[*ast.Ident]types.Object{},
+			Selections: map[*ast.SelectorExpr]*types.Selection{},
+		},
+	}
+
+	for _, name := range lp.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(lp.Dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg.Files = append(pkg.Files, f)
+	}
+
+	conf := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if p, ok := lp.ImportMap[path]; ok {
+				path = p
+			}
+			return gc.Import(path)
+		}),
+	}
+
+	tpkg, err := conf.Check(lp.ImportPath, fset, pkg.Files, pkg.TypesInfo)
+	if err != nil {
+		return nil, err
+	}
+	pkg.Types = tpkg
+
+	return pkg, nil
+}
@@ -0,0 +1,152 @@
+package lint
+
+import (
+	"go/ast"
+	"strings"
+	"unicode/utf8"
+)
+
+// PrintfAnalyzer 检查 errors 包中格式化函数的格式化字符串：
+//
+//	WithCode、WrapC、Wrapf、Errorf、WithMessagef 的格式化字符串不是常量且没有参数
+//	格式化指令与参数个数不一致
+//	只有 WithCode、WrapC 以及它们的 Params、Fields 变体支持 %w
+//	Wrap 的 message 会被当作格式化字符串使用，不能包含格式化指令
+var PrintfAnalyzer = &Analyzer{
+	Name: "printf",
+	Doc:  "check format strings passed to WithCode, WrapC, Wrapf, Errorf and Wrap",
+	Run:  runPrintf,
+}
+
+// printfFuncs 是格式化函数及其格式化字符串参数的位置
+var printfFuncs = map[string]int{
+	"WithCode":       1,
+	"WrapC":          2,
+	"WithCodeParams": 2,
+	"WrapCParams":    3,
+	"WithCodeFields": 2,
+	"WrapCFields":    3,
+	"Wrapf":          1,
+	"Errorf":         0,
+	"WithMessagef":   1,
+}
+
+// wrapErrorfFuncs 是使用 fmt.Errorf 格式化、支持 %w 的函数
+var wrapErrorfFuncs = map[string]bool{
+	"WithCode":       true,
+	"WrapC":          true,
+	"WithCodeParams": true,
+	"WrapCParams":    true,
+	"WithCodeFields": true,
+	"WrapCFields":    true,
+}
+
+func runPrintf(pass *Pass) error {
+	inspect(pass, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		fn := callee(pass.TypesInfo, call)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != ErrorsPath {
+			return true
+		}
+
+		if fn.Name() == "Wrap" {
+			checkWrap(pass, call)
+			return true
+		}
+
+		idx, ok := printfFuncs[fn.Name()]
+		if !ok || len(call.Args) <= idx || call.Ellipsis.IsValid() {
+			return true
+		}
+
+		format, ok := stringConst(pass.TypesInfo, call.Args[idx])
+		nargs := len(call.Args) - idx - 1
+		if !ok {
+			if nargs == 0 {
+				pass.Reportf(call.Args[idx].Pos(), "non-constant format string in call to %s", fn.Name())
+			}
+			return true
+		}
+
+		want, wrap, indexed := countVerbs(format)
+		if wrap && !wrapErrorfFuncs[fn.Name()] {
+			pass.Reportf(call.Args[idx].Pos(), "%s does not support error-wrapping directive %%w", fn.Name())
+		}
+
+		if !indexed && want != nargs {
+			pass.Reportf(call.Pos(), "%s format %q reads %d args, but call has %d args", fn.Name(), format, want, nargs)
+		}
+
+		return true
+	})
+
+	return nil
+}
+
+// checkWrap 检查 Wrap 的 message，包装 withCode 错误时 message 会被当作格式化字符串使用
+func checkWrap(pass *Pass, call *ast.CallExpr) {
+	if len(call.Args) != 2 {
+		return
+	}
+
+	msg, ok := stringConst(pass.TypesInfo, call.Args[1])
+	if !ok {
+		pass.Reportf(call.Args[1].Pos(), "non-constant message in call to Wrap, use Wrapf(err, \"%%s\", msg)")
+		return
+	}
+
+	if want, _, _ := countVerbs(msg); want > 0 {
+		pass.Reportf(call.Args[1].Pos(), "Wrap message %q contains formatting directives, use Wrapf", msg)
+	}
+}
+
+// countVerbs 返回格式化字符串需要的参数个数、是否包含 %w、是否使用了显式参数索引
+func countVerbs(format string) (n int, wrap, indexed bool) {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+
+		i++
+		// flags
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+
+		// width、precision
+	width:
+		for i < len(format) {
+			switch c := format[i]; {
+			case c == '[':
+				indexed = true
+			case c == '*':
+				n++
+			case c == '.' || c == ']' || ('0' <= c && c <= '9'):
+			default:
+				break width
+			}
+			i++
+		}
+
+		if i >= len(format) {
+			return
+		}
+
+		r, size := utf8.DecodeRuneInString(format[i:])
+		i += size - 1
+		if r == '%' {
+			continue
+		}
+
+		if r == 'w' {
+			wrap = true
+		}
+		n++
+	}
+
+	return
+}
@@ -0,0 +1,61 @@
+package codes
+
+import "github.com/tiandh987/errors"
+
+const (
+	ErrA   = 110001
+	ErrB   = 110002
+	ErrDup = 110003
+)
+
+var errC = errors.NewCoder(110004, 400, "C", "")
+
+var (
+	other  = errors.NewRegistry()
+	loaded = errors.NewRegistry()
+)
+
+type wrapped struct {
+	errors.Coder
+}
+
+func init() {
+	errors.MustRegister(errors.NewCoder(ErrA, 400, "A", ""))
+	errors.MustRegister(errC)
+	errors.MustRegister(wrapped{errors.NewCoder(110005, 400, "E", "")})
+	errors.MustRegister(errors.NewCoder(ErrDup, 400, "Dup", ""))
+	errors.MustRegister(errors.NewCoder(ErrDup, 400, "Dup again", "")) // want `code 110003 is already registered in the same registry at`
+	errors.MustRegisterAlias(110006, ErrA)
+
+	// 一次注册多个 Coder
+	errors.MustRegister(
+		errors.NewCoder(110007, 400, "G", ""),
+		errors.NewCoder(ErrDup, 400, "Dup in a batch", ""), // want `code 110003 is already registered in the same registry at`
+	)
+	batch := []errors.Coder{errors.NewCoder(110008, 400, "H", "")}
+	other.MustRegister(batch...)
+
+	// 不同的 Registry 可以注册相同的错误码
+	other.MustRegister(errors.NewCoder(ErrA, 404, "A in another registry", ""))
+
+	// 创建 Coder 不是注册
+	_ = errors.NewCoder(110009, 400, "Not registered", "")
+
+	// 动态注册的 Registry
+	_ = loaded.LoadCatalogFile("codes.json")
+	for i := 1; i <= 10; i++ {
+		other.MustRegister(errors.NewCoder(120000+i, 400, "Dynamic", ""))
+	}
+}
+
+func Use(err error) error {
+	_ = errors.WithCode(110004, "c")
+	_ = errors.WithCode(110005, "e")
+	_ = errors.WithCode(110007, "g")
+	_ = errors.IsCode(err, 110006)
+	_ = errors.WithCode(110009, "x") // want `code 110009 passed to WithCode is not registered`
+	_ = loaded.WithCode(130001, "from catalog")
+	_ = other.WithCode(120001, "registered in a loop")
+
+	return errors.WrapC(err, ErrB, "b") // want `code 110002 passed to WrapC is not registered`
+}
@@ -0,0 +1,7 @@
+module lintdata
+
+go 1.17
+
+require github.com/tiandh987/errors v0.0.0
+
+replace github.com/tiandh987/errors => ../../..
@@ -0,0 +1,24 @@
+package printf
+
+import "github.com/tiandh987/errors"
+
+const code = 210001
+
+func init() {
+	errors.MustRegister(errors.NewCoder(code, 400, "Bad request", ""))
+}
+
+func Use(err error, msg string, id int) {
+	_ = errors.WithCode(code, "user %d not found", id)
+	_ = errors.WithCodeFields(code, map[string]interface{}{"tenant": "t1"}, "user %d not found", id)
+	_ = errors.WrapCFields(err, code, nil, "user %d not found") // want `WrapCFields format "user %d not found" reads 1 args, but call has 0 args`
+	_ = errors.WithCode(code, "user %d not found")              // want `WithCode format "user %d not found" reads 1 args, but call has 0 args`
+	_ = errors.WithCode(code, msg)                              // want `non-constant format string in call to WithCode`
+	_ = errors.WrapC(err, code, "wrapped %w", err)
+	_ = errors.Wrapf(err, "wrapped %w", err) // want `Wrapf does not support error-wrapping directive %w`
+	_ = errors.Errorf("%s and %s", "a")      // want `Errorf format "%s and %s" reads 2 args, but call has 1 args`
+	_ = errors.Wrap(err, "failed")
+	_ = errors.Wrap(err, "failed: %s") // want `Wrap message "failed: %s" contains formatting directives, use Wrapf`
+	_ = errors.Wrap(err, msg)          // want `non-constant message in call to Wrap`
+	_ = errors.WithMessagef(err, "%[1]s %[1]s", msg)
+}
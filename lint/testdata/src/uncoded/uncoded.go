@@ -0,0 +1,51 @@
+package uncoded
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"github.com/tiandh987/errors"
+)
+
+const ErrNotFound = 310001
+
+func init() {
+	errors.MustRegister(errors.NewCoder(ErrNotFound, 404, "Not found", ""))
+}
+
+func Get(id string) error {
+	if id == "" {
+		return errors.New("empty id") // want `exported Get returns an error without a code from errors.New`
+	}
+	if id == "-" {
+		return stderrors.New("bad id") // want `exported Get returns an error without a code from errors.New`
+	}
+
+	return fmt.Errorf("user %s", id) // want `exported Get returns an error without a code from fmt.Errorf`
+}
+
+func Find(id string) error {
+	return errors.WithCode(ErrNotFound, "user %s not found", id)
+}
+
+func get() error {
+	return errors.New("unexported functions are not checked")
+}
+
+func Lazy() func() error {
+	return func() error {
+		return errors.New("closures are not checked")
+	}
+}
+
+type store struct{}
+
+func (store) Get() error {
+	return errors.New("methods of unexported types are not checked")
+}
+
+type Store struct{}
+
+func (*Store) Get() error {
+	return errors.New("gone") // want `exported Get returns an error without a code from errors.New`
+}
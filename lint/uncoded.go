@@ -0,0 +1,104 @@
+package lint
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// UncodedAnalyzer 检查导出函数（以及导出类型的导出方法）直接返回不带错误码的错误，例如：
+//
+//	func Get(id string) error {
+//		return errors.New("not found") // 应使用 errors.WithCode
+//	}
+//
+// 识别的构造函数包括本包的 New、Errorf，标准库的 errors.New 以及 fmt.Errorf。
+// main 包以及 errors 模块自身的包（errors、lint 等基础设施，它们的错误不属于任何业务错误码）不会被检查。
+var UncodedAnalyzer = &Analyzer{
+	Name: "uncoded",
+	Doc:  "check for exported functions returning errors without a code",
+	Run:  runUncoded,
+}
+
+// uncodedFuncs 是创建不带错误码错误的函数，key 为包路径
+var uncodedFuncs = map[string][]string{
+	ErrorsPath: {"New", "Errorf"},
+	"errors":   {"New"},
+	"fmt":      {"Errorf"},
+}
+
+func runUncoded(pass *Pass) error {
+	if pass.Pkg.Name() == "main" || pass.Pkg.Path() == ErrorsPath || strings.HasPrefix(pass.Pkg.Path(), ErrorsPath+"/") {
+		return nil
+	}
+
+	for _, f := range pass.Files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil || !exported(pass, fd) {
+				continue
+			}
+
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.FuncLit:
+					// 闭包的返回值不会直接离开当前包
+					return false
+				case *ast.ReturnStmt:
+					for _, res := range n.Results {
+						call, ok := unparen(res).(*ast.CallExpr)
+						if !ok {
+							continue
+						}
+
+						if fn := callee(pass.TypesInfo, call); uncoded(fn) {
+							pass.Reportf(call.Pos(), "exported %s returns an error without a code from %s.%s, use WithCode or WrapC", fd.Name.Name, fn.Pkg().Name(), fn.Name())
+						}
+					}
+				}
+
+				return true
+			})
+		}
+	}
+
+	return nil
+}
+
+// exported 报告函数是否可以被其他包调用
+func exported(pass *Pass, fd *ast.FuncDecl) bool {
+	if !fd.Name.IsExported() {
+		return false
+	}
+
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return true
+	}
+
+	t := pass.TypesInfo.TypeOf(fd.Recv.List[0].Type)
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Exported()
+}
+
+// uncoded 报告 fn 是否创建不带错误码的错误
+func uncoded(fn *types.Func) bool {
+	if fn == nil || fn.Pkg() == nil {
+		return false
+	}
+
+	if sig, ok := fn.Type().(*types.Signature); ok && sig.Recv() != nil {
+		return false
+	}
+
+	for _, name := range uncodedFuncs[fn.Pkg().Path()] {
+		if fn.Name() == name {
+			return true
+		}
+	}
+
+	return false
+}
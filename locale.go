@@ -0,0 +1,356 @@
+package errors
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 文件内容：
+//	1、type LocalizedCoder interface
+//		可选的 Coder 扩展接口，由 Coder 自己提供多语言外部错误文本。
+//
+//	2、Registry 的多语言消息目录
+//		(1)添加：AddMessages()
+//		(2)默认语言：SetDefaultLocale()、DefaultLocale()
+//		(3)渲染：Localized()、Localize()、LocalizeAccept()
+//		(4)检查缺失的翻译：MissingTranslations()
+//
+//	3、语言回退链：FallbackChain()，例如 zh-CN => zh => en
+//	   Accept-Language 解析：ParseAcceptLanguage()
+//
+// 查找某个错误码在某种语言下的外部错误文本时，按照回退链依次查找，
+// 每种语言先查找 LocalizedCoder，再查找 Registry 的消息目录，
+// 默认语言（DefaultLocale）没有翻译时使用 Coder.String()。
+// 错误带有参数（WithCodeParams、WrapCParams）时，外部错误文本中的占位符会被填充，见 template.go。
+
+// DefaultLocale 是 Registry 默认的回退语言。
+const DefaultLocale = "en"
+
+// LocalizedCoder 是可以提供多语言外部错误文本的 Coder。
+type LocalizedCoder interface {
+	Coder
+
+	// LocalizedString 返回 locale 语言的外部错误文本，没有该语言的文本时 ok 为 false。
+	LocalizedString(locale string) (msg string, ok bool)
+}
+
+// normalizeLocale 规范化语言标签，例如 zh_CN => zh-cn
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// FallbackChain 返回 locale 的回退链，依次去掉最后一个子标签，最后回退到 fallback，
+// 例如 FallbackChain("zh-Hant-TW", "en") 返回 [zh-hant-tw zh-hant zh en]。
+// 返回的语言标签都经过规范化（小写，使用 - 分隔）。
+func FallbackChain(locale, fallback string) []string {
+	var chain []string
+
+	seen := NewString()
+	add := func(l string) {
+		if l != "" && !seen.Has(l) {
+			seen.Insert(l)
+			chain = append(chain, l)
+		}
+	}
+
+	for l := normalizeLocale(locale); l != ""; {
+		add(l)
+
+		i := strings.LastIndex(l, "-")
+		if i < 0 {
+			break
+		}
+		l = l[:i]
+	}
+	add(normalizeLocale(fallback))
+
+	return chain
+}
+
+// ParseAcceptLanguage 解析 HTTP Accept-Language 头，按照权重从高到低返回语言标签，
+// 权重为 0 以及通配符 * 会被忽略。
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		locale := strings.TrimSpace(fields[0])
+		if locale == "" || locale == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+
+			v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			if err == nil {
+				q = v
+			}
+		}
+
+		if q > 0 {
+			langs = append(langs, weighted{locale: locale, q: q})
+		}
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+
+	locales := make([]string, 0, len(langs))
+	for _, l := range langs {
+		locales = append(locales, l.locale)
+	}
+
+	return locales
+}
+
+// ===================================================================
+// AddMessages 为 locale 语言添加错误码的外部错误文本，相同错误码的文本会被覆盖。
+func (r *Registry) AddMessages(locale string, messages map[int]string) {
+	locale = normalizeLocale(locale)
+
+	r.mustUpdate(func(next *registryState) error {
+		catalog := make(map[int]string, len(next.locales[locale])+len(messages))
+		for code, msg := range next.locales[locale] {
+			catalog[code] = msg
+		}
+		for code, msg := range messages {
+			catalog[code] = msg
+		}
+		next.locales[locale] = catalog
+
+		return nil
+	})
+}
+
+// SetDefaultLocale 设置回退链最后使用的语言，默认为 DefaultLocale。
+func (r *Registry) SetDefaultLocale(locale string) {
+	r.mustUpdate(func(next *registryState) error {
+		next.defaultLocale = normalizeLocale(locale)
+		return nil
+	})
+}
+
+// DefaultLocale 返回回退链最后使用的语言。
+func (r *Registry) DefaultLocale() string {
+	if locale := r.load().defaultLocale; locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Locales 返回 r 中有消息目录的所有语言，按照字母排序。
+func (r *Registry) Locales() []string {
+	return StringKeySet(r.load().locales).List()
+}
+
+// candidates 返回 coder 在 locale 语言下的候选外部错误文本，不进行回退，
+// 依次为 LocalizedCoder 返回的文本、消息目录中的文本。
+func (r *Registry) candidates(coder Coder, locale string) []string {
+	var msgs []string
+	if lc, ok := coder.(LocalizedCoder); ok {
+		if msg, ok := lc.LocalizedString(locale); ok {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	if msg, ok := r.catalogMessage(coder.Code(), locale); ok {
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+// message 返回 coder 在 locale 语言下使用 params 渲染的外部错误文本，不进行回退。
+// 占位符缺少参数的候选文本会被跳过，默认语言没有可用的翻译时使用 renderMessage()。
+func (r *Registry) message(coder Coder, locale string, params Params) (string, bool) {
+	for _, tmpl := range r.candidates(coder, locale) {
+		if msg, ok := expand(tmpl, params); ok {
+			return msg, true
+		}
+	}
+
+	if locale == r.DefaultLocale() {
+		return renderMessage(coder, params), true
+	}
+
+	return "", false
+}
+
+// catalogMessage 返回消息目录中 code 在 locale 语言下的外部错误文本
+func (r *Registry) catalogMessage(code int, locale string) (string, bool) {
+	msg, ok := r.load().locales[locale][code]
+	return msg, ok
+}
+
+// lookupMessage 按照 locales 的顺序依次查找 coder 的外部错误文本，每种语言都会回退到父语言。
+func (r *Registry) lookupMessage(coder Coder, params Params, locales ...string) (string, bool) {
+	for _, locale := range locales {
+		for _, l := range FallbackChain(locale, "") {
+			if msg, ok := r.message(coder, l, params); ok {
+				return msg, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// Localized 返回 coder 在 locale 语言下的视图，其 String() 返回按照回退链找到的外部错误文本。
+// 视图没有参数，包含占位符的文本不会被使用。
+func (r *Registry) Localized(coder Coder, locale string) Coder {
+	return r.localized(coder, nil, locale)
+}
+
+func (r *Registry) localized(coder Coder, params Params, locales ...string) Coder {
+	if coder == nil {
+		return nil
+	}
+
+	msg, ok := r.lookupMessage(coder, params, append(locales[:len(locales):len(locales)], r.DefaultLocale())...)
+	if !ok || msg == coder.String() {
+		return coder
+	}
+
+	return localizedCoder{Coder: coder, msg: msg}
+}
+
+// Localize 返回 err 在 locale 语言下的外部错误文本。
+func (r *Registry) Localize(err error, locale string) string {
+	return r.localizeWith(err, locale)
+}
+
+// LocalizeAccept 返回 err 在 HTTP Accept-Language 头 header 所指定语言下的外部错误文本。
+func (r *Registry) LocalizeAccept(err error, header string) string {
+	return r.localizeWith(err, ParseAcceptLanguage(header)...)
+}
+
+func (r *Registry) localizeWith(err error, locales ...string) string {
+	if err == nil {
+		return ""
+	}
+	r.renderedErr(err)
+
+	coder := r.localized(r.ParseCoder(err), paramsOf(err), locales...)
+	if msg := coder.String(); msg != "" {
+		return msg
+	}
+
+	// 与 %s 格式化相同，外部错误文本为空时使用错误信息
+	if v, ok := firstCoded(err); ok {
+		return v.err.Error()
+	}
+
+	return errorText(err)
+}
+
+// MissingTranslations 返回每种语言缺少翻译的错误码，按照错误码排序，
+// locales 为空时检查 r 中有消息目录的所有语言。没有缺失时返回空 map。
+func (r *Registry) MissingTranslations(locales ...string) map[string][]int {
+	if len(locales) == 0 {
+		locales = r.Locales()
+	}
+
+	missing := map[string][]int{}
+	for _, coder := range r.coders() {
+		for _, locale := range locales {
+			locale = normalizeLocale(locale)
+			if locale != r.DefaultLocale() && len(r.candidates(coder, locale)) == 0 {
+				missing[locale] = append(missing[locale], coder.Code())
+			}
+		}
+	}
+
+	return missing
+}
+
+// localizedCoder 使用指定的外部错误文本替代 Coder.String()，
+// 其他可选接口（RPCCoder、LocalizedCoder、MetaCoder、HeaderCoder、TemplateCoder）转发给原来的 Coder。
+type localizedCoder struct {
+	Coder
+	msg string
+}
+
+func (coder localizedCoder) String() string {
+	return coder.msg
+}
+
+// RPCStatus 转发给原来的 Coder，原来的 Coder 没有声明时由 HTTP 状态码推导。
+func (coder localizedCoder) RPCStatus() RPCCode {
+	return coderRPCStatus(coder.Coder)
+}
+
+// LocalizedString 转发给原来的 Coder。
+func (coder localizedCoder) LocalizedString(locale string) (string, bool) {
+	if lc, ok := coder.Coder.(LocalizedCoder); ok {
+		return lc.LocalizedString(locale)
+	}
+
+	return "", false
+}
+
+// Template 转发给原来的 Coder。
+func (coder localizedCoder) Template() string {
+	return templateOf(coder.Coder)
+}
+
+func (coder localizedCoder) Severity() Severity {
+	return coderMeta(coder.Coder).Severity()
+}
+
+func (coder localizedCoder) Owner() string {
+	return coderMeta(coder.Coder).Owner()
+}
+
+func (coder localizedCoder) Remediation() string {
+	return coderMeta(coder.Coder).Remediation()
+}
+
+func (coder localizedCoder) Description() string {
+	return coderMeta(coder.Coder).Description()
+}
+
+// Header 转发给原来的 Coder。
+func (coder localizedCoder) Header() http.Header {
+	if hc, ok := coder.Coder.(HeaderCoder); ok {
+		return hc.Header()
+	}
+
+	return nil
+}
+
+// ===================================================================
+// AddMessages 为默认 Registry 添加 locale 语言的外部错误文本。
+func AddMessages(locale string, messages map[int]string) {
+	defaultRegistry.AddMessages(locale, messages)
+}
+
+// Localize 使用渲染 err 的 Registry 返回 err 在 locale 语言下的外部错误文本。
+func Localize(err error, locale string) string {
+	return registryOf(err).Localize(err, locale)
+}
+
+// LocalizeAccept 使用渲染 err 的 Registry 返回 err 在 Accept-Language 头所指定语言下的外部错误文本。
+func LocalizeAccept(err error, header string) string {
+	return registryOf(err).LocalizeAccept(err, header)
+}
+
+// registryOf 返回渲染 err 的错误树中第一个 withCode 错误的 Registry
+func registryOf(err error) *Registry {
+	if v, ok := firstCoded(err); ok {
+		return v.renderer()
+	}
+
+	return defaultRegistry
+}
@@ -0,0 +1,139 @@
+package errors
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestFallbackChain(t *testing.T) {
+	tests := []struct {
+		locale   string
+		fallback string
+		want     []string
+	}{
+		{"zh-Hant-TW", "en", []string{"zh-hant-tw", "zh-hant", "zh", "en"}},
+		{"zh_CN", "en", []string{"zh-cn", "zh", "en"}},
+		{"en-US", "en", []string{"en-us", "en"}},
+		{" EN ", "en", []string{"en"}},
+		{"fr", "", []string{"fr"}},
+		{"", "en", []string{"en"}},
+		{"", "", nil},
+	}
+
+	for _, tt := range tests {
+		if got := FallbackChain(tt.locale, tt.fallback); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FallbackChain(%q, %q) = %q, want %q", tt.locale, tt.fallback, got, tt.want)
+		}
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []string
+	}{
+		{"q ordering", "en;q=0.5, zh-CN, fr;q=0.8", []string{"zh-CN", "fr", "en"}},
+		{"equal q keeps order", "de;q=0.5, fr;q=0.5", []string{"de", "fr"}},
+		{"q=0 ignored", "de;q=0, en", []string{"en"}},
+		{"wildcard ignored", "fr-CH, fr;q=0.9, *;q=0.5", []string{"fr-CH", "fr"}},
+		{"only wildcard", "*", []string{}},
+		{"invalid q", "en;q=abc", []string{"en"}},
+		{"empty", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizeAccept(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(210001, 404, "User not found", ""))
+	r.AddMessages("zh", map[int]string{210001: "用户不存在"})
+	r.AddMessages("fr", map[int]string{210001: "Utilisateur introuvable"})
+
+	err := r.WithCode(210001, "user %d", 1)
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"fallback to parent", "zh-CN,zh;q=0.9", "用户不存在"},
+		{"first translated", "de, fr;q=0.8, zh;q=0.5", "Utilisateur introuvable"},
+		{"q ordering", "zh;q=0.5, fr", "Utilisateur introuvable"},
+		{"q=0 ignored", "fr;q=0, zh;q=0.5", "用户不存在"},
+		{"no translation", "de", "User not found"},
+		{"wildcard", "*", "User not found"},
+		{"empty", "", "User not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.LocalizeAccept(err, tt.header); got != tt.want {
+				t.Errorf("LocalizeAccept(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+
+			// 包级别函数使用渲染错误的 Registry
+			if got := LocalizeAccept(Wrap(err, "get user"), tt.header); got != tt.want {
+				t.Errorf("package LocalizeAccept(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+
+	if got := r.LocalizeAccept(nil, "zh"); got != "" {
+		t.Errorf("LocalizeAccept(nil) = %q", got)
+	}
+}
+
+// headerRPCCoder 声明了 RPC 状态码和 HTTP 头的 Coder
+type headerRPCCoder struct {
+	rpcCoder
+}
+
+func (coder headerRPCCoder) Header() http.Header {
+	return http.Header{"Retry-After": []string{"30"}}
+}
+
+func TestLocalizedForwardsOptionalInterfaces(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(
+		headerRPCCoder{rpcCoder{defaultCoder{C: 210101, HTTP: 400, Ext: "Stale"}, RPCFailedPrecondition}},
+		NewTemplateCoder(210102, 404, "User {name} not found", "User not found", ""),
+		RichCoder{C: 210103, HTTP: 500, Ext: "Internal", Team: "identity"},
+	)
+	r.AddMessages("zh", map[int]string{210101: "数据已过期", 210102: "用户不存在", 210103: "内部错误"})
+
+	localized := func(code int) Coder {
+		coder, _ := r.Lookup(code)
+		return r.Localized(coder, "zh-CN")
+	}
+
+	stale := localized(210101)
+	if got := stale.String(); got != "数据已过期" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := coderRPCStatus(stale); got != RPCFailedPrecondition {
+		t.Errorf("RPCStatus() = %v, want %v", got, RPCFailedPrecondition)
+	}
+	if hc, ok := stale.(HeaderCoder); !ok || hc.Header().Get("Retry-After") != "30" {
+		t.Errorf("Header() not forwarded")
+	}
+
+	if got := templateOf(localized(210102)); got != "User {name} not found" {
+		t.Errorf("Template() = %q", got)
+	}
+
+	if got := coderRPCStatus(localized(210103)); got != RPCInternal {
+		t.Errorf("RPCStatus() of a coder without RPC status = %v, want %v", got, RPCInternal)
+	}
+	if got := coderMeta(localized(210103)).Owner(); got != "identity" {
+		t.Errorf("Owner() = %q", got)
+	}
+}
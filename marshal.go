@@ -0,0 +1,390 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 文件内容：
+//	1、type ErrorRecord struct
+//		错误链的 JSON 表示，fundamental、withStack、withMessage、withCode、withFields、
+//		聚合错误的 MarshalJSON 都输出 ErrorRecord。
+//
+//	2、type JSONError struct
+//		可以作为 JSON 字段反序列化的错误，UnmarshalJSON 根据 ErrorRecord 重建等价的错误链
+//
+//	3、type OpaqueError struct
+//		其他包的错误重建之后的类型，保留原错误的类型名和错误信息
+//
+// JSON 格式（ErrorRecord）：
+//	{
+//	  "kind":    "withCode",            // 错误的类型，见下面的说明
+//	  "type":    "*url.Error",          // 仅 opaque、custom：原错误的 Go 类型名或者注册的类型名
+//	  "code":    110001,                // 仅 withCode：错误码（即使没有注册）
+//	  "http":    404,                   // 仅 withCode：HTTP 状态码
+//	  "message": "User not found",      // Error() 的返回值，withCode 为外部错误文本
+//	  "error":   "user bob not found",  // withCode 的内部错误信息，withMessage 添加的信息
+//	  "params":  {"name": "bob"},       // 仅 withCode：模板参数
+//	  "fields":  {"tenant": "t1"},      // withCode、withFields 的字段
+//	  "frames":  ["main.main /src/main.go:12"], // 调用栈，格式与 Frame.MarshalText() 相同
+//	  "data":    {...},                 // 仅 custom：注册的编码函数返回的数据，见 encode.go
+//	  "cause":   {...},                 // 被包装的错误
+//	  "errors":  [{...}]                // aggregate 以及实现了 Unwrap() []error 的 opaque：被包装的每个错误
+//	}
+//	kind 的取值为 fundamental、withStack、withMessage、withCode、withFields、aggregate、custom、opaque，
+//	通过 RegisterErrorType 注册的类型为 custom，其他包的错误为 opaque，实现了 Unwrap() error 时同样输出 cause。
+//
+// 重建的错误链由该包的错误类型组成，因此 IsCode、Code、ParseCoder 等函数仍然可以使用；
+// 错误码在默认 Registry 中没有注册时，使用记录的 HTTP 状态码和外部错误文本渲染。
+// 重建的错误没有本地的调用栈，记录的远程调用栈 frames 在 %-v、%+v 等格式化中替代本地的调用栈输出，
+// 再次序列化时原样输出。重建的错误总是使用默认 Registry 渲染。
+//
+// Is 的规则：JSONError 的错误链中，类型相同且错误码、错误信息相同的 fundamental、withMessage、withCode
+// 与 target 视为相同；OpaqueError 与类型名、错误信息相同的 target 视为相同，例如 io.EOF。
+
+const (
+	kindFundamental = "fundamental"
+	kindWithStack   = "withStack"
+	kindWithMessage = "withMessage"
+	kindWithCode    = "withCode"
+	kindWithFields  = "withFields"
+	kindAggregate   = "aggregate"
+	kindOpaque      = "opaque"
+)
+
+// ErrorRecord 是错误链中一个错误的 JSON 表示。
+type ErrorRecord struct {
+	Kind     string                 `json:"kind"`
+	Type     string                 `json:"type,omitempty"`
+	Code     int                    `json:"code,omitempty"`
+	HTTP     int                    `json:"http,omitempty"`
+	Message  string                 `json:"message"`
+	Internal string                 `json:"error,omitempty"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+	Frames   []string               `json:"frames,omitempty"`
+	Data     json.RawMessage        `json:"data,omitempty"`
+	Cause    *ErrorRecord           `json:"cause,omitempty"`
+	Errors   []*ErrorRecord         `json:"errors,omitempty"`
+}
+
+// NewErrorRecord 返回 err 的 JSON 表示，err 为 nil 时返回 nil。
+func NewErrorRecord(err error) *ErrorRecord {
+	if err == nil {
+		return nil
+	}
+
+	switch v := err.(type) {
+	case *fundamental:
+		return &ErrorRecord{Kind: kindFundamental, Message: v.msg, Frames: framesOf(v.stack, v.frames)}
+	case *withStack:
+		return &ErrorRecord{Kind: kindWithStack, Message: errorText(v), Frames: framesOf(v.stack, v.frames), Cause: NewErrorRecord(v.error)}
+	case *withMessage:
+		return &ErrorRecord{Kind: kindWithMessage, Message: errorText(v), Internal: v.msg, Cause: NewErrorRecord(v.cause)}
+	case *withCode:
+		coder := v.coderIn(v.renderer().load())
+		return &ErrorRecord{
+			Kind:     kindWithCode,
+			Code:     v.code,
+			HTTP:     coder.HTTPStatus(),
+			Message:  buildFormatInfo(v, snapshots{}).message,
+			Internal: v.err.Error(),
+			Params:   v.params,
+			Fields:   v.fields,
+			Frames:   framesOf(v.stack, v.frames),
+			Cause:    NewErrorRecord(v.cause),
+		}
+	case *withFields:
+		return &ErrorRecord{Kind: kindWithFields, Message: errorText(v), Fields: v.fields, Cause: NewErrorRecord(v.cause)}
+	case Aggregate:
+		rec := &ErrorRecord{Kind: kindAggregate, Message: errorText(v)}
+		for _, e := range v.Errors() {
+			rec.Errors = append(rec.Errors, NewErrorRecord(e))
+		}
+		return rec
+	case *JSONError:
+		if v.rec != nil {
+			return v.rec
+		}
+		return NewErrorRecord(v.err)
+	case *OpaqueError:
+		return &ErrorRecord{Kind: kindOpaque, Type: v.Type, Message: v.Msg, Cause: NewErrorRecord(v.Err)}
+	}
+
+	if rec, ok := customRecord(err); ok {
+		return rec
+	}
+
+	rec := &ErrorRecord{Kind: kindOpaque, Type: fmt.Sprintf("%T", err), Message: errorText(err)}
+	if w, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range w.Unwrap() {
+			rec.Errors = append(rec.Errors, NewErrorRecord(e))
+		}
+		return rec
+	}
+	rec.Cause = NewErrorRecord(unwrap(err))
+
+	return rec
+}
+
+// framesOf 返回调用栈中每一帧的文本表示，没有调用栈时（重建的错误）返回记录的远程调用栈 recorded
+func framesOf(st *stack, recorded []string) []string {
+	if st == nil || len(*st) == 0 {
+		return recorded
+	}
+
+	var frames []string
+	for _, f := range st.StackTrace() {
+		text, _ := f.MarshalText()
+		frames = append(frames, string(text))
+	}
+
+	return frames
+}
+
+// splitFrame 将 Frame.MarshalText() 的文本 "function file:line" 拆分为函数名和位置，
+// 没有位置（例如 "unknown"）时 location 为空
+func splitFrame(text string) (name, location string) {
+	if i := strings.IndexByte(text, ' '); i > 0 {
+		return text[:i], text[i+1:]
+	}
+
+	return text, ""
+}
+
+// formatFrames 按照 %+v 格式化调用栈的格式输出记录的远程调用栈
+func formatFrames(w io.Writer, frames []string) {
+	for _, text := range frames {
+		name, location := splitFrame(text)
+		io.WriteString(w, "\n"+name)
+		if location != "" {
+			io.WriteString(w, "\n\t"+location)
+		}
+	}
+}
+
+// Err 根据 rec 重建等价的错误链，rec 为 nil 时返回 nil。
+func (rec *ErrorRecord) Err() error {
+	if rec == nil {
+		return nil
+	}
+
+	cause := rec.Cause.Err()
+	switch rec.Kind {
+	case kindFundamental:
+		return &fundamental{msg: rec.Message, stack: &stack{}, frames: rec.Frames}
+	case kindWithStack:
+		if cause != nil {
+			return &withStack{error: cause, stack: &stack{}, frames: rec.Frames}
+		}
+	case kindWithMessage:
+		if cause != nil {
+			return &withMessage{cause: cause, msg: rec.Internal}
+		}
+	case kindWithCode:
+		w := &withCode{
+			err:    fmt.Errorf("%s", rec.Internal),
+			code:   rec.Code,
+			cause:  cause,
+			params: rec.Params,
+			fields: rec.Fields,
+			stack:  &stack{},
+			frames: rec.Frames,
+		}
+		if _, ok := Lookup(rec.Code); !ok {
+			w.coder = defaultCoder{C: rec.Code, HTTP: rec.HTTP, Ext: rec.Message}
+		}
+		return w
+	case kindWithFields:
+		if cause != nil {
+			return &withFields{cause: cause, fields: rec.Fields}
+		}
+	case kindAggregate:
+		errs := make([]error, 0, len(rec.Errors))
+		for _, e := range rec.Errors {
+			errs = append(errs, e.Err())
+		}
+		if agg := NewAggregate(errs); agg != nil {
+			return agg
+		}
+	case kindCustom:
+		if err, ok := rec.customErr(cause); ok {
+			return err
+		}
+	}
+
+	// opaque、未知的 kind、无法解码的 custom 以及缺少 cause 的包装错误，
+	// 包装多个错误的 opaque（例如包含多个 %w 的 fmt.Errorf）使用 Join 重建被包装的错误
+	typ := rec.Type
+	if typ == "" {
+		typ = rec.Kind
+	}
+
+	if cause == nil && len(rec.Errors) > 0 {
+		errs := make([]error, 0, len(rec.Errors))
+		for _, e := range rec.Errors {
+			errs = append(errs, e.Err())
+		}
+		cause = Join(errs...)
+	}
+
+	return &OpaqueError{Type: typ, Msg: rec.Message, Err: cause}
+}
+
+// ===================================================================
+// MarshalJSON 实现 json.Marshaler，输出 ErrorRecord。
+func (f *fundamental) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewErrorRecord(f))
+}
+
+// MarshalJSON 实现 json.Marshaler，输出 ErrorRecord。
+func (w *withStack) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewErrorRecord(w))
+}
+
+// MarshalJSON 实现 json.Marshaler，输出 ErrorRecord。
+func (w *withMessage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewErrorRecord(w))
+}
+
+// MarshalJSON 实现 json.Marshaler，输出 ErrorRecord。
+func (w *withCode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewErrorRecord(w))
+}
+
+// MarshalJSON 实现 json.Marshaler，输出 ErrorRecord。
+func (w *withFields) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewErrorRecord(w))
+}
+
+// MarshalJSON 实现 json.Marshaler，输出 ErrorRecord。
+func (agg aggregate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewErrorRecord(agg))
+}
+
+// ===================================================================
+// OpaqueError 是其他包的错误重建之后的错误，保留原错误的类型名和错误信息。
+type OpaqueError struct {
+	// Type 原错误的 Go 类型名，例如 *url.Error
+	Type string
+
+	// Msg 原错误的错误信息
+	Msg string
+
+	// Err 原错误包装的错误，可以为 nil
+	Err error
+}
+
+func (e *OpaqueError) Error() string {
+	return e.Msg
+}
+
+func (e *OpaqueError) Unwrap() error {
+	return e.Err
+}
+
+// Is 报告 target 的类型名和错误信息是否与原错误相同。
+func (e *OpaqueError) Is(target error) bool {
+	return target != nil && fmt.Sprintf("%T", target) == e.Type && target.Error() == e.Msg
+}
+
+// MarshalJSON 实现 json.Marshaler，输出 ErrorRecord。
+func (e *OpaqueError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewErrorRecord(e))
+}
+
+// ===================================================================
+// JSONError 是可以序列化和反序列化的错误，用于 API、审计日志等结构体中的错误字段：
+//
+//	type AuditEvent struct {
+//		Err *errors.JSONError `json:"err,omitempty"`
+//	}
+//	event := AuditEvent{Err: errors.NewJSONError(err)}
+//
+// 反序列化时根据 ErrorRecord 重建等价的错误链，Unwrap 返回重建的错误，
+// 因此 IsCode、Code 等函数可以直接用于 JSONError。
+type JSONError struct {
+	err error
+	rec *ErrorRecord
+}
+
+// NewJSONError 返回包装 err 的 JSONError，err 为 nil 时返回 nil。
+func NewJSONError(err error) *JSONError {
+	if err == nil {
+		return nil
+	}
+
+	return &JSONError{err: err}
+}
+
+func (e *JSONError) Error() string {
+	if e.err == nil {
+		return ""
+	}
+
+	return e.err.Error()
+}
+
+// Unwrap 返回包装或者重建的错误。
+func (e *JSONError) Unwrap() error {
+	return e.err
+}
+
+// Record 返回 e 的 JSON 表示，反序列化得到的 JSONError 返回反序列化的数据（包括调用栈）。
+func (e *JSONError) Record() *ErrorRecord {
+	return NewErrorRecord(e)
+}
+
+// Format 使用相同的格式化指令格式化包装或者重建的错误。
+func (e *JSONError) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, directive(s, verb), e.err)
+}
+
+// Is 报告 e 的错误树中是否有与 target 相同的 fundamental、withMessage、withCode 错误，
+// 类型相同且错误码、错误信息相同时视为相同。
+func (e *JSONError) Is(target error) bool {
+	if t, ok := target.(*JSONError); ok {
+		target = t.err
+	}
+
+	return walk(e.err, func(err error) bool {
+		return sameError(err, target)
+	})
+}
+
+// sameError 报告 err 与 target 是否是类型相同且错误码、错误信息相同的该包的错误
+func sameError(err, target error) bool {
+	switch v := err.(type) {
+	case *fundamental:
+		t, ok := target.(*fundamental)
+		return ok && v.msg == t.msg
+	case *withMessage:
+		t, ok := target.(*withMessage)
+		return ok && v.msg == t.msg
+	case *withCode:
+		t, ok := target.(*withCode)
+		return ok && v.code == t.code && v.err.Error() == t.err.Error()
+	}
+
+	return false
+}
+
+// MarshalJSON 实现 json.Marshaler，输出 ErrorRecord。
+func (e *JSONError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Record())
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，根据 ErrorRecord 重建错误链。
+func (e *JSONError) UnmarshalJSON(data []byte) error {
+	var rec *ErrorRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return Wrapf(err, "unmarshal error record failed: %v", err)
+	}
+
+	e.rec = rec
+	e.err = rec.Err()
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// formatVerbs 是错误支持的所有格式化指令
+var formatVerbs = []string{"%s", "%v", "%q", "%-v", "%+v", "%#v", "%#-v", "%#+v"}
+
+func TestErrorRecordRoundTripFormatsWithEveryVerb(t *testing.T) {
+	MustRegister(NewCoder(170001, 404, "Order not found", ""))
+	defer Unregister(170001)
+
+	errs := map[string]error{
+		"withCode":  WithCode(170001, "get order %d", 7),
+		"wrapped":   WrapC(Wrap(New("no rows"), "query"), 170001, "get order"),
+		"remote":    WrapC(WithStack(New("timeout")), 170099, "call remote"),
+		"fields":    WithFields(WithCode(170001, "get order"), "order", 7),
+		"aggregate": NewAggregate([]error{WithCode(170001, "a"), New("b")}),
+	}
+
+	for name, err := range errs {
+		t.Run(name, func(t *testing.T) {
+			data, marshalErr := json.Marshal(NewJSONError(err))
+			if marshalErr != nil {
+				t.Fatalf("Marshal() = %v", marshalErr)
+			}
+
+			var decoded JSONError
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Unmarshal() = %v", err)
+			}
+
+			for _, rebuilt := range []error{NewErrorRecord(err).Err(), &decoded} {
+				for _, verb := range formatVerbs {
+					// fmt 会恢复 Format 中的 panic，并将其输出为 %!v(PANIC=...)
+					if got := fmt.Sprintf(verb, rebuilt); got == "" || strings.Contains(got, "PANIC") {
+						t.Errorf("Sprintf(%q) of %T = %q", verb, rebuilt, got)
+					}
+				}
+
+				if got, want := fmt.Sprintf("%s", rebuilt), fmt.Sprintf("%s", err); got != want {
+					t.Errorf("%%s = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
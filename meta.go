@@ -0,0 +1,238 @@
+package errors
+
+import (
+	"strconv"
+	"strings"
+)
+
+// 文件内容：
+//	1、type Severity int
+//		错误的严重程度：debug、info、warning、error、critical
+//
+//	2、type MetaCoder interface
+//		可选的 Coder 扩展接口，提供给值班人员使用的元数据：
+//		严重程度、负责团队、处理步骤、内部描述
+//
+//	3、type RichCoder struct
+//		实现 MetaCoder 的 Coder，Reference() 由 reference 模板和错误码生成
+//
+//	4、ExpandReference() 展开 reference 模板
+//
+//	5、文档锚点
+//		Anchor() 返回错误码在文档中的锚点，锚点只与错误码有关，保持稳定
+//		ReferenceURL() 返回指向文档中某个错误码的 URL，可作为 Coder.Reference()
+//		文档由 docgen 包生成（见 docgen.WriteMarkdown、docgen.WriteHTML）
+//
+// 使用 %#-v、%#+v 格式化错误时，MetaCoder 的元数据会输出到 JSON 中。
+// 废弃标记不属于 Coder 的元数据，而是由 Registry 统一管理（Registry.Deprecate()、目录的 deprecated 字段，见 alias.go），
+// JSON 中的 deprecated、replacement 来自渲染该错误的 Registry。
+
+// Severity 是错误的严重程度，零值表示未指定。
+type Severity int
+
+const (
+	SeverityDebug Severity = iota + 1
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+var severityNames = [...]string{
+	SeverityDebug:    "debug",
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityError:    "error",
+	SeverityCritical: "critical",
+}
+
+// String 返回严重程度的名称，未指定时返回空字符串。
+func (s Severity) String() string {
+	if s > 0 && int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	if s == 0 {
+		return ""
+	}
+	return "severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// MarshalText 实现 encoding.TextMarshaler。
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler。
+func (s *Severity) UnmarshalText(text []byte) error {
+	v, ok := ParseSeverity(string(text))
+	if !ok {
+		return Errorf("unknown severity %q", text)
+	}
+
+	*s = v
+	return nil
+}
+
+// ParseSeverity 解析严重程度的名称，不区分大小写。
+func ParseSeverity(name string) (Severity, bool) {
+	name = strings.ToLower(name)
+	if name == "" {
+		return 0, true
+	}
+
+	for s, n := range severityNames {
+		if n != "" && n == name {
+			return Severity(s), true
+		}
+	}
+
+	return 0, false
+}
+
+// MetaCoder 是提供元数据的 Coder。
+type MetaCoder interface {
+	Coder
+
+	// Severity 返回错误的严重程度
+	Severity() Severity
+
+	// Owner 返回负责该错误码的团队
+	Owner() string
+
+	// Remediation 返回处理该错误的步骤
+	Remediation() string
+
+	// Description 返回内部描述，与外部（用户）可见的错误文本不同，不应返回给用户
+	Description() string
+}
+
+// ===================================================================
+// RichCoder 是实现了 MetaCoder 的 Coder。
+type RichCoder struct {
+	// C 错误码
+	C int
+
+	// HTTP 该错误码对应的 HTTP 状态码
+	HTTP int
+
+	// Ext 外部（用户）可见的错误文本
+	Ext string
+
+	// Ref reference 文档的模板，见 ExpandReference
+	Ref string
+
+	// Sev 严重程度
+	Sev Severity
+
+	// Team 负责团队
+	Team string
+
+	// Fix 处理步骤
+	Fix string
+
+	// Desc 内部描述
+	Desc string
+}
+
+func (coder RichCoder) Code() int {
+	return coder.C
+}
+
+func (coder RichCoder) HTTPStatus() int {
+	if coder.HTTP == 0 {
+		return 500
+	}
+	return coder.HTTP
+}
+
+func (coder RichCoder) String() string {
+	return coder.Ext
+}
+
+// Reference 返回使用错误码展开 reference 模板得到的 URL。
+func (coder RichCoder) Reference() string {
+	return ExpandReference(coder.Ref, coder.C)
+}
+
+func (coder RichCoder) Severity() Severity {
+	return coder.Sev
+}
+
+func (coder RichCoder) Owner() string {
+	return coder.Team
+}
+
+func (coder RichCoder) Remediation() string {
+	return coder.Fix
+}
+
+func (coder RichCoder) Description() string {
+	return coder.Desc
+}
+
+// ExpandReference 使用错误码展开 reference 模板：
+// 模板中的 {code} 会被替换为错误码；
+// 模板不包含 {code} 时，将其视为文档地址，返回指向该错误码锚点的 URL（见 ReferenceURL），
+// 已经包含锚点（#）的地址原样返回；模板为空时返回空字符串。
+//
+//	ExpandReference("https://example.com/errors/{code}", 110001)  => https://example.com/errors/110001
+//	ExpandReference("https://example.com/errors.html", 110001)    => https://example.com/errors.html#code-110001
+func ExpandReference(tmpl string, code int) string {
+	if tmpl == "" {
+		return ""
+	}
+
+	if strings.Contains(tmpl, "{code}") {
+		return strings.ReplaceAll(tmpl, "{code}", strconv.Itoa(code))
+	}
+
+	if i := strings.IndexByte(tmpl, '#'); i >= 0 && i < len(tmpl)-1 {
+		return tmpl
+	}
+
+	return ReferenceURL(tmpl, code)
+}
+
+// Anchor 返回错误码在文档中的锚点。
+func Anchor(code int) string {
+	return "code-" + strconv.Itoa(code)
+}
+
+// ReferenceURL 返回指向文档 base 中错误码 code 的 URL。
+func ReferenceURL(base string, code int) string {
+	return strings.TrimSuffix(base, "#") + "#" + Anchor(code)
+}
+
+// coderMeta 返回 coder 的元数据，coder 没有实现 MetaCoder 时所有元数据都为零值。
+// 包装 Coder 的类型（reloadedCoder、localizedCoder）使用它转发元数据。
+func coderMeta(coder Coder) MetaCoder {
+	if mc, ok := coder.(MetaCoder); ok {
+		return mc
+	}
+
+	return RichCoder{}
+}
+
+// metaFields 返回 coder 的非零元数据，用于 JSON 格式的输出
+func metaFields(coder Coder) map[string]interface{} {
+	mc, ok := coder.(MetaCoder)
+	if !ok {
+		return nil
+	}
+
+	fields := map[string]interface{}{}
+	if s := mc.Severity(); s != 0 {
+		fields["severity"] = s.String()
+	}
+	if owner := mc.Owner(); owner != "" {
+		fields["owner"] = owner
+	}
+	if fix := mc.Remediation(); fix != "" {
+		fields["remediation"] = fix
+	}
+	if desc := mc.Description(); desc != "" {
+		fields["description"] = desc
+	}
+
+	return fields
+}
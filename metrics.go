@@ -0,0 +1,241 @@
+package errors
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync/atomic"
+)
+
+// 文件内容：
+//	1、错误码计数：Registry.EnableMetrics()、Registry.DisableMetrics()、Registry.Metrics()、Registry.ResetMetrics()
+//		按照错误码和 HTTP 状态码统计创建和渲染带错误码的错误的次数，默认关闭。
+//
+//	2、导出：Registry.MetricsHandler()     Prometheus 文本格式（text/plain; version=0.0.4）
+//	        通过 expvar 导出见 expvarmetrics 包，errors 包不引入 expvar，
+//	        以免在 http.DefaultServeMux 上注册 /debug/vars
+//
+//	3、errorText() 包内部渲染错误链中的错误时使用，不计入渲染次数
+//
+// 创建指 WithCode、WrapC、WithCodeParams、WrapCParams（以及 Registry 的同名方法）的调用，
+// 通过 Wrap、WithStack 等为已有的错误添加新的一层不计入创建，DecodeResponse、FromProblem 还原的错误、
+// ErrorHandler 恢复的 panic 也不计入。
+// 渲染指对外的渲染入口的每一次调用：带错误码的错误以及聚合错误的格式化（%s、%v 等）和 Error()、
+// Localize()、NewProblem()，计入错误树中第一个带错误码的错误。
+// 包内部渲染错误链（例如聚合错误拼接每个错误、%+v 输出每一层错误、编码为 ErrorRecord）不计数，
+// 因此一次渲染只计数一次；同一个错误渲染多次（例如写入响应并记录日志）会计数多次。
+//
+// 计数使用创建、渲染时的 Coder：别名计入新错误码，HTTP 状态码为当时 Coder 的 HTTP 状态码。
+// 计数器第一次出现之后，计数只是一次 map 查找和一次原子加法，不需要加锁，也不会分配内存。
+
+const (
+	// metricCreated 创建次数的 Prometheus 指标名
+	metricCreated = "errors_created_total"
+
+	// metricRendered 渲染次数的 Prometheus 指标名
+	metricRendered = "errors_rendered_total"
+
+	// metricsContentType 是 Prometheus 文本格式的 Content-Type
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// CodeMetric 是一个错误码和 HTTP 状态码的计数。
+type CodeMetric struct {
+	Code     int    `json:"code"`
+	HTTP     int    `json:"http"`
+	Created  uint64 `json:"created"`
+	Rendered uint64 `json:"rendered"`
+}
+
+// metricKey 是计数器的键
+type metricKey struct {
+	code int
+	http int
+}
+
+// codeCounter 是一个错误码和 HTTP 状态码的计数器，字段只能原子地访问
+type codeCounter struct {
+	created  uint64
+	rendered uint64
+}
+
+// counterMap 返回当前的计数器
+func (r *Registry) counterMap() map[metricKey]*codeCounter {
+	m, _ := r.counters.Load().(map[metricKey]*codeCounter)
+	return m
+}
+
+// counter 返回 code、status 对应的计数器，不存在时创建。
+// 计数器的集合与 Registry 的状态相同，写时复制，读取时不需要将 key 转换为 interface{}
+func (r *Registry) counter(code, status int) *codeCounter {
+	key := metricKey{code: code, http: status}
+	if c, ok := r.counterMap()[key]; ok {
+		return c
+	}
+
+	r.countersMux.Lock()
+	defer r.countersMux.Unlock()
+
+	m := r.counterMap()
+	if c, ok := m[key]; ok {
+		return c
+	}
+
+	next := make(map[metricKey]*codeCounter, len(m)+1)
+	for k, c := range m {
+		next[k] = c
+	}
+	c := &codeCounter{}
+	next[key] = c
+	r.counters.Store(next)
+
+	return c
+}
+
+// metricsEnabled 报告 r 是否开启了计数
+func (r *Registry) metricsEnabled() bool {
+	return atomic.LoadInt32(&r.metrics) == 1
+}
+
+// created 在创建错误码为 code 的错误时调用：有废弃的错误码时输出废弃警告，开启计数时增加创建次数
+func (r *Registry) created(code int) {
+	state := r.load()
+	if state.hasDeprecations() {
+		r.warnDeprecated(state, code)
+	}
+
+	if !r.metricsEnabled() {
+		return
+	}
+
+	c := r.counter(state.resolve(code), state.coder(code).HTTPStatus())
+	atomic.AddUint64(&c.created, 1)
+}
+
+// rendered 增加 coder 的渲染次数
+func (r *Registry) rendered(coder Coder) {
+	c := r.counter(coder.Code(), coder.HTTPStatus())
+	atomic.AddUint64(&c.rendered, 1)
+}
+
+// renderedErr 在对外的渲染入口调用，开启计数时增加 err 的错误树中第一个带错误码的错误的渲染次数
+func (r *Registry) renderedErr(err error) {
+	if !r.metricsEnabled() {
+		return
+	}
+
+	if v, ok := firstCoded(err); ok {
+		r.rendered(v.coderIn(r.load()))
+	}
+}
+
+// errorText 返回 err.Error()，但不计入渲染次数，用于包内部渲染错误链中的错误。
+// 包内的错误类型不调用对外的 Error()，其他错误类型调用 Error()。
+func errorText(err error) string {
+	switch v := err.(type) {
+	case *withCode:
+		return v.text()
+	case *withStack:
+		return errorText(v.error)
+	case *withFields:
+		return errorText(v.cause)
+	case aggregate:
+		return v.text()
+	}
+
+	return err.Error()
+}
+
+// ===================================================================
+// EnableMetrics 开启计数，已有的计数保留。
+func (r *Registry) EnableMetrics() {
+	atomic.StoreInt32(&r.metrics, 1)
+}
+
+// DisableMetrics 关闭计数，已有的计数保留。
+func (r *Registry) DisableMetrics() {
+	atomic.StoreInt32(&r.metrics, 0)
+}
+
+// ResetMetrics 清空所有计数。
+func (r *Registry) ResetMetrics() {
+	r.countersMux.Lock()
+	defer r.countersMux.Unlock()
+
+	r.counters.Store(map[metricKey]*codeCounter{})
+}
+
+// Metrics 返回所有计数，按照错误码和 HTTP 状态码排序。
+func (r *Registry) Metrics() []CodeMetric {
+	metrics := []CodeMetric{}
+	for k, c := range r.counterMap() {
+		metrics = append(metrics, CodeMetric{
+			Code:     k.code,
+			HTTP:     k.http,
+			Created:  atomic.LoadUint64(&c.created),
+			Rendered: atomic.LoadUint64(&c.rendered),
+		})
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Code != metrics[j].Code {
+			return metrics[i].Code < metrics[j].Code
+		}
+		return metrics[i].HTTP < metrics[j].HTTP
+	})
+
+	return metrics
+}
+
+// MetricsHandler 返回以 Prometheus 文本格式输出 r 的计数的 http.Handler，例如：
+//
+//	# HELP errors_created_total Number of errors created with a code.
+//	# TYPE errors_created_total counter
+//	errors_created_total{code="110001",http="404"} 3
+//	# HELP errors_rendered_total Number of times errors with a code were rendered.
+//	# TYPE errors_rendered_total counter
+//	errors_rendered_total{code="110001",http="404"} 5
+func (r *Registry) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", metricsContentType)
+
+		metrics := r.Metrics()
+		bw := bufio.NewWriter(w)
+
+		fmt.Fprintf(bw, "# HELP %s Number of errors created with a code.\n", metricCreated)
+		fmt.Fprintf(bw, "# TYPE %s counter\n", metricCreated)
+		for _, m := range metrics {
+			fmt.Fprintf(bw, "%s{code=\"%d\",http=\"%d\"} %d\n", metricCreated, m.Code, m.HTTP, m.Created)
+		}
+
+		fmt.Fprintf(bw, "# HELP %s Number of times errors with a code were rendered.\n", metricRendered)
+		fmt.Fprintf(bw, "# TYPE %s counter\n", metricRendered)
+		for _, m := range metrics {
+			fmt.Fprintf(bw, "%s{code=\"%d\",http=\"%d\"} %d\n", metricRendered, m.Code, m.HTTP, m.Rendered)
+		}
+
+		_ = bw.Flush()
+	})
+}
+
+// ===================================================================
+// EnableMetrics 开启默认 Registry 的计数。
+func EnableMetrics() {
+	defaultRegistry.EnableMetrics()
+}
+
+// DisableMetrics 关闭默认 Registry 的计数。
+func DisableMetrics() {
+	defaultRegistry.DisableMetrics()
+}
+
+// Metrics 返回默认 Registry 的所有计数。
+func Metrics() []CodeMetric {
+	return defaultRegistry.Metrics()
+}
+
+// MetricsHandler 返回以 Prometheus 文本格式输出默认 Registry 的计数的 http.Handler。
+func MetricsHandler() http.Handler {
+	return defaultRegistry.MetricsHandler()
+}
@@ -0,0 +1,99 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+// renderCount 返回 code 的渲染次数
+func renderCount(r *Registry, code int) uint64 {
+	var n uint64
+	for _, m := range r.Metrics() {
+		if m.Code == code {
+			n += m.Rendered
+		}
+	}
+
+	return n
+}
+
+func TestMetricsCountPublicRendersOnce(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(
+		NewCoder(160001, 404, "User not found", ""),
+		NewCoder(160002, 500, "Database error", ""),
+	)
+	r.EnableMetrics()
+
+	inner := r.WithCode(160002, "query")
+	chain := WithStack(Wrap(r.WrapC(inner, 160001, "get user"), "handler"))
+	agg := NewAggregate([]error{r.WithCode(160001, "a"), r.WithCode(160002, "b")})
+
+	tests := []struct {
+		name   string
+		render func()
+	}{
+		{"%s", func() { _ = fmt.Sprintf("%s", r.WithCode(160001, "x")) }},
+		{"Error", func() { _ = r.WithCode(160001, "x").Error() }},
+		{"%+v chain", func() { _ = fmt.Sprintf("%+v", chain) }},
+		{"%#+v chain", func() { _ = fmt.Sprintf("%#+v", chain) }},
+		{"chain Error", func() { _ = chain.Error() }},
+		{"aggregate Error", func() { _ = agg.Error() }},
+		{"fields", func() { _ = fmt.Sprintf("%-v", WithFields(r.WithCode(160001, "x"), "user", "alice")) }},
+		{"Localize", func() { _ = r.Localize(chain, "en") }},
+		{"NewProblem", func() { _ = r.NewProblem(chain, "") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.ResetMetrics()
+			tt.render()
+
+			if got := renderCount(r, 160001); got != 1 {
+				t.Errorf("rendered 160001 %d times, want 1", got)
+			}
+			if got := renderCount(r, 160002); got != 0 {
+				t.Errorf("rendered 160002 %d times, want 0", got)
+			}
+		})
+	}
+}
+
+func TestMetricsInternalRendersNotCounted(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(160001, 404, "User not found", ""))
+	err := r.WithCode(160001, "x")
+	r.EnableMetrics()
+
+	_ = InternalMessage(err)
+	_ = Encode(err)
+	_ = NewErrorRecord(err)
+
+	if got := renderCount(r, 160001); got != 0 {
+		t.Errorf("internal renders counted %d times", got)
+	}
+}
+
+func TestMetricsCounterDoesNotAllocate(t *testing.T) {
+	r := NewRegistry()
+	r.counter(160001, 404)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		r.counter(160001, 404)
+	})
+	if allocs != 0 {
+		t.Errorf("counter() allocates %v times per call", allocs)
+	}
+}
+
+func TestCreatedWithoutDeprecations(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(160001, 404, "User not found", ""))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		r.created(160001)
+	})
+	if allocs != 0 {
+		t.Errorf("created() allocates %v times per call with metrics off", allocs)
+	}
+}
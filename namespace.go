@@ -0,0 +1,279 @@
+package errors
+
+import (
+	"fmt"
+	"sync"
+)
+
+// 文件内容：
+//	1、type Layout struct
+//		错误码的分段布局，例如 SSMMNN：
+//			SS 服务（service），MM 模块（module），NN 序号（sequence）
+//		(1)拆分：Split()
+//		(2)组合：Code()
+//
+//	2、type Namespace struct
+//		基于 Layout 管理一个 Registry 中的错误码：
+//		(1)创建：NewNamespace()
+//		(2)预留模块：Reserve()
+//		(3)注册：Register()、MustRegister()，拒绝不在已预留模块范围内的错误码
+//		(4)按族查询：IsCodeFamily()、Codes()
+//
+//		第一次预留模块之后，ns 的 Registry 中前缀与 ns 相同的错误码无论通过什么方式注册
+//		（包括 Registry.Register 以及包级别的 Register、MustRegister），都必须属于已预留的模块，
+//		预留之前已经注册的错误码不会被检查。
+//
+//	3、DefaultNamespace()、包级别 IsCodeFamily()，使用 DefaultLayout 解析默认 Registry 的错误码
+//
+// 服务前缀：
+//	多个服务共用一个错误码目录时，可以为每个服务的 Namespace 指定不同的前缀，
+//	前缀位于 Layout 的最高位之前，例如前缀 3 + SSMMNN 110001 => 3110001。
+
+// AnyModule 用于 IsCodeFamily、Codes，表示匹配服务下的所有模块。
+const AnyModule = -1
+
+// Layout 描述错误码各段的位数。
+type Layout struct {
+	// Service 服务段的位数
+	Service int
+
+	// Module 模块段的位数
+	Module int
+
+	// Sequence 序号段的位数
+	Sequence int
+}
+
+// DefaultLayout 是 SSMMNN 布局。
+var DefaultLayout = Layout{Service: 2, Module: 2, Sequence: 2}
+
+// Split 将错误码拆分为前缀、服务、模块以及序号。
+func (l Layout) Split(code int) (prefix, service, module, sequence int) {
+	sequence = code % pow10(l.Sequence)
+	code /= pow10(l.Sequence)
+
+	module = code % pow10(l.Module)
+	code /= pow10(l.Module)
+
+	service = code % pow10(l.Service)
+	prefix = code / pow10(l.Service)
+
+	return
+}
+
+// Code 使用前缀、服务、模块以及序号组合错误码。
+// 任意一段超出该段位数时将会引发 panic。
+func (l Layout) Code(prefix, service, module, sequence int) int {
+	l.mustFit("service", service, l.Service)
+	l.mustFit("module", module, l.Module)
+	l.mustFit("sequence", sequence, l.Sequence)
+
+	code := prefix
+	code = code*pow10(l.Service) + service
+	code = code*pow10(l.Module) + module
+	code = code*pow10(l.Sequence) + sequence
+
+	return code
+}
+
+// fits 报告 v 是否可以使用 digits 位数表示。
+func (l Layout) fits(v, digits int) bool {
+	return v >= 0 && v < pow10(digits)
+}
+
+func (l Layout) mustFit(segment string, v, digits int) {
+	if !l.fits(v, digits) {
+		panic(fmt.Sprintf("%s `%d` does not fit in %d digits", segment, v, digits))
+	}
+}
+
+// pow10 返回 10 的 n 次方
+func pow10(n int) int {
+	p := 1
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
+
+// ===================================================================
+// Namespace 基于 Layout 管理一个 Registry 中某个前缀下的错误码。
+type Namespace struct {
+	registry *Registry
+	layout   Layout
+	prefix   int
+
+	mux      sync.Mutex
+	modules  map[[2]int]string
+	enforced bool // 是否已经为 registry 添加了检查预留模块的校验
+}
+
+// NewNamespace 创建一个 Namespace，registry 为 nil 时使用默认 Registry，
+// prefix 为 0 表示没有服务前缀。
+func NewNamespace(registry *Registry, layout Layout, prefix int) *Namespace {
+	if registry == nil {
+		registry = defaultRegistry
+	}
+
+	return &Namespace{
+		registry: registry,
+		layout:   layout,
+		prefix:   prefix,
+		modules:  map[[2]int]string{},
+	}
+}
+
+// Layout 返回 ns 的分段布局。
+func (ns *Namespace) Layout() Layout {
+	return ns.layout
+}
+
+// Registry 返回 ns 使用的 Registry。
+func (ns *Namespace) Registry() *Registry {
+	return ns.registry
+}
+
+// Code 使用 ns 的前缀组合错误码。
+func (ns *Namespace) Code(service, module, sequence int) int {
+	return ns.layout.Code(ns.prefix, service, module, sequence)
+}
+
+// Reserve 为服务预留一个模块，只有已预留模块范围内的错误码才能通过 ns 注册。
+// name 用于冲突时的错误提示。第一次预留时 ns 的 Registry 已经冻结将会返回错误。
+func (ns *Namespace) Reserve(service, module int, name string) error {
+	if !ns.layout.fits(service, ns.layout.Service) {
+		return Errorf("namespace: service `%d` does not fit in %d digits", service, ns.layout.Service)
+	}
+
+	if !ns.layout.fits(module, ns.layout.Module) {
+		return Errorf("namespace: module `%d` does not fit in %d digits", module, ns.layout.Module)
+	}
+
+	ns.mux.Lock()
+
+	key := [2]int{service, module}
+	if owner, ok := ns.modules[key]; ok {
+		ns.mux.Unlock()
+		return Errorf("namespace: service %d module %d already reserved by %q", service, module, owner)
+	}
+	ns.modules[key] = name
+
+	enforce := !ns.enforced
+	ns.enforced = true
+	ns.mux.Unlock()
+
+	// 校验在 registry 的写锁中运行并获取 ns.mux，因此添加校验时不能持有 ns.mux。
+	// registry 已经冻结时无法添加校验，撤销本次预留并返回错误
+	if enforce {
+		err := ns.registry.update(func(next *registryState) error {
+			next.validators = append(next.validators[:len(next.validators):len(next.validators)], ns.validator)
+			return nil
+		})
+		if err != nil {
+			ns.mux.Lock()
+			delete(ns.modules, key)
+			ns.enforced = false
+			ns.mux.Unlock()
+
+			return Errorf("namespace: reserve service %d module %d: %s", service, module, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// MustReserve 与 Reserve 相同，预留失败时将会引发 panic。
+func (ns *Namespace) MustReserve(service, module int, name string) {
+	if err := ns.Reserve(service, module, name); err != nil {
+		panic(err.Error())
+	}
+}
+
+// check 检查错误码是否属于 ns 中已预留的模块。
+func (ns *Namespace) check(code int) error {
+	prefix, service, module, _ := ns.layout.Split(code)
+	if prefix != ns.prefix {
+		return Errorf("namespace: code %d does not have prefix %d", code, ns.prefix)
+	}
+
+	ns.mux.Lock()
+	_, ok := ns.modules[[2]int{service, module}]
+	ns.mux.Unlock()
+
+	if !ok {
+		return Errorf("namespace: code %d is outside reserved modules, service %d module %d not reserved", code, service, module)
+	}
+
+	return nil
+}
+
+// validator 是 ns 为 Registry 添加的校验，前缀与 ns 相同的错误码必须属于已预留的模块，
+// 其他前缀的错误码由对应的 Namespace 检查
+func (ns *Namespace) validator(coder Coder) error {
+	if prefix, _, _, _ := ns.layout.Split(coder.Code()); prefix != ns.prefix {
+		return nil
+	}
+
+	return ns.check(coder.Code())
+}
+
+// Register 检查 coder 的错误码属于已预留的模块后，将其注册到 ns 的 Registry。
+// 与 MustRegister 相同，已经注册过的 code 会被视为错误。
+func (ns *Namespace) Register(coder Coder) error {
+	if err := ns.check(coder.Code()); err != nil {
+		return err
+	}
+
+	return ns.registry.registerAll([]Coder{coder}, []Site{callerSite()})
+}
+
+// MustRegister 与 Register 相同，注册失败时将会引发 panic。
+func (ns *Namespace) MustRegister(coder Coder) {
+	if err := ns.Register(coder); err != nil {
+		panic(err.Error())
+	}
+}
+
+// inFamily 报告 code 是否属于 ns 前缀下的服务和模块。
+func (ns *Namespace) inFamily(code, service, module int) bool {
+	p, s, m, _ := ns.layout.Split(code)
+	return p == ns.prefix && s == service && (module == AnyModule || m == module)
+}
+
+// IsCodeFamily 报告错误树中是否包含由 ns 的 Registry 渲染、属于给定服务和模块的错误码，
+// 与 Registry.IsCode 相同，别名先解析为新错误码再判断所属的族。
+// module 为 AnyModule 时匹配服务下的所有模块。
+func (ns *Namespace) IsCodeFamily(err error, service, module int) bool {
+	state := ns.registry.load()
+
+	return walk(err, func(e error) bool {
+		v, ok := e.(*withCode)
+		return ok && v.renderer() == ns.registry && ns.inFamily(state.resolve(v.code), service, module)
+	})
+}
+
+// Codes 返回 ns 的 Registry 中属于给定服务和模块的所有 Coder，按照错误码排序，
+// module 为 AnyModule 时返回服务下的所有 Coder。
+func (ns *Namespace) Codes(service, module int) []Coder {
+	var coders []Coder
+	for _, coder := range ns.registry.coders() {
+		if ns.inFamily(coder.Code(), service, module) {
+			coders = append(coders, coder)
+		}
+	}
+
+	return coders
+}
+
+// defaultNamespace 使用 DefaultLayout 解析默认 Registry 的错误码。
+var defaultNamespace = NewNamespace(nil, DefaultLayout, 0)
+
+// DefaultNamespace 返回使用 DefaultLayout、没有前缀的默认 Registry 的 Namespace。
+func DefaultNamespace() *Namespace {
+	return defaultNamespace
+}
+
+// IsCodeFamily 按照 DefaultLayout 报告错误链中是否包含属于给定服务和模块的错误码。
+func IsCodeFamily(err error, service, module int) bool {
+	return defaultNamespace.IsCodeFamily(err, service, module)
+}
@@ -0,0 +1,179 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLayoutSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout Layout
+		code   int
+
+		prefix, service, module, sequence int
+	}{
+		{"ssmmnn", DefaultLayout, 110203, 0, 11, 2, 3},
+		{"leading zero module", DefaultLayout, 110001, 0, 11, 0, 1},
+		{"prefix", DefaultLayout, 3110203, 3, 11, 2, 3},
+		{"short code", DefaultLayout, 1203, 0, 0, 12, 3},
+		{"custom layout", Layout{Service: 3, Module: 1, Sequence: 3}, 1234567, 0, 123, 4, 567},
+		{"custom layout with prefix", Layout{Service: 1, Module: 2, Sequence: 3}, 98765432, 98, 7, 65, 432},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, service, module, sequence := tt.layout.Split(tt.code)
+			if prefix != tt.prefix || service != tt.service || module != tt.module || sequence != tt.sequence {
+				t.Errorf("Split(%d) = %d, %d, %d, %d, want %d, %d, %d, %d", tt.code,
+					prefix, service, module, sequence, tt.prefix, tt.service, tt.module, tt.sequence)
+			}
+
+			if got := tt.layout.Code(prefix, service, module, sequence); got != tt.code {
+				t.Errorf("Code(Split(%d)) = %d", tt.code, got)
+			}
+		})
+	}
+}
+
+func TestLayoutCodePanicsOnOverflow(t *testing.T) {
+	tests := []struct {
+		name                      string
+		service, module, sequence int
+		want                      string
+	}{
+		{"service", 100, 1, 1, "service `100`"},
+		{"module", 11, 100, 1, "module `100`"},
+		{"sequence", 11, 1, 100, "sequence `100`"},
+		{"negative", 11, -1, 1, "module `-1`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if r == nil || !strings.Contains(fmt.Sprint(r), tt.want) {
+					t.Errorf("Code() panic = %v, want %q", r, tt.want)
+				}
+			}()
+
+			DefaultLayout.Code(0, tt.service, tt.module, tt.sequence)
+		})
+	}
+}
+
+func TestNamespaceRejectsCodesOutsideReservedModules(t *testing.T) {
+	r := NewRegistry()
+	ns := NewNamespace(r, DefaultLayout, 0)
+
+	// 预留之前注册的错误码不会被检查
+	r.MustRegister(NewCoder(199901, 500, "Legacy", ""))
+
+	ns.MustReserve(19, 1, "user")
+	if err := ns.Reserve(19, 1, "account"); err == nil || !strings.Contains(err.Error(), `"user"`) {
+		t.Errorf("Reserve() of a reserved module = %v", err)
+	}
+	if err := ns.Reserve(19, 100, "overflow"); err == nil {
+		t.Error("Reserve() of a module that does not fit returned nil")
+	}
+
+	tests := []struct {
+		name    string
+		coder   Coder
+		wantErr string
+	}{
+		{"reserved module", NewCoder(190101, 404, "User not found", ""), ""},
+		{"module not reserved", NewCoder(190201, 404, "Order not found", ""), "service 19 module 2 not reserved"},
+		{"service not reserved", NewCoder(200101, 404, "Item not found", ""), "service 20 module 1 not reserved"},
+		{"other prefix", NewCoder(3190301, 404, "Other service", ""), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 通过 Registry 注册同样会被检查，其他前缀的错误码由对应的 Namespace 检查
+			err := r.TryRegister(tt.coder)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("TryRegister() = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("TryRegister() = %v, want %q", err, tt.wantErr)
+			}
+			if _, ok := r.Lookup(tt.coder.Code()); ok {
+				t.Errorf("code %d registered", tt.coder.Code())
+			}
+		})
+	}
+
+	if err := ns.Register(NewCoder(190102, 400, "Invalid user", "")); err != nil {
+		t.Errorf("Namespace.Register() = %v", err)
+	}
+	if err := ns.Register(NewCoder(3190302, 400, "Other service", "")); err == nil || !strings.Contains(err.Error(), "prefix 0") {
+		t.Errorf("Namespace.Register() of another prefix = %v", err)
+	}
+}
+
+func TestNamespaceReserveOnFrozenRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Freeze()
+
+	ns := NewNamespace(r, DefaultLayout, 0)
+	if err := ns.Reserve(19, 1, "user"); err == nil || !strings.Contains(err.Error(), "frozen") {
+		t.Fatalf("Reserve() on a frozen registry = %v", err)
+	}
+
+	// 失败的预留被撤销
+	if err := ns.check(190101); err == nil {
+		t.Error("check() accepted a code of a failed reservation")
+	}
+}
+
+func TestNamespaceIsCodeFamily(t *testing.T) {
+	r := NewRegistry()
+	r.SetLogf(func(string, ...interface{}) {})
+	ns := NewNamespace(r, DefaultLayout, 0)
+	ns.MustReserve(19, 1, "user")
+	ns.MustReserve(19, 2, "order")
+	ns.MustReserve(19, 3, "legacy")
+
+	ns.MustRegister(NewCoder(190101, 404, "User not found", ""))
+	ns.MustRegister(NewCoder(190201, 404, "Order not found", ""))
+	ns.MustRegister(NewCoder(190301, 404, "Legacy user not found", ""))
+
+	// 旧错误码 190301 是 190101 的别名，按照新错误码所属的族判断
+	r.Unregister(190301)
+	r.MustRegisterAlias(190301, 190101)
+
+	tests := []struct {
+		name            string
+		err             error
+		service, module int
+		want            bool
+	}{
+		{"same module", r.WithCode(190101, "user"), 19, 1, true},
+		{"other module", r.WithCode(190201, "order"), 19, 1, false},
+		{"any module", r.WithCode(190201, "order"), 19, AnyModule, true},
+		{"other service", r.WithCode(190101, "user"), 20, AnyModule, false},
+		{"wrapped", Wrap(r.WithCode(190101, "user"), "get user"), 19, 1, true},
+		{"alias", r.WithCode(190301, "legacy"), 19, 1, true},
+		{"alias old module", r.WithCode(190301, "legacy"), 19, 3, false},
+		{"default registry", WithCode(190101, "user"), 19, 1, false},
+		{"plain error", New("user"), 19, AnyModule, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ns.IsCodeFamily(tt.err, tt.service, tt.module); got != tt.want {
+				t.Errorf("IsCodeFamily(%v, %d, %d) = %v, want %v", tt.err, tt.service, tt.module, got, tt.want)
+			}
+		})
+	}
+
+	if got := len(ns.Codes(19, AnyModule)); got != 2 {
+		t.Errorf("len(Codes(19, AnyModule)) = %d, want 2", got)
+	}
+}
@@ -0,0 +1,232 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// 文件内容：
+//	1、type Problem struct
+//		RFC 7807 定义的 application/problem+json 文档，
+//		扩展成员 code 为错误码，cause 为被包装的带错误码的错误，errors 为聚合错误中的每个错误。
+//
+//	2、渲染：Registry.NewProblem()、NewProblem()
+//	   解析：ParseProblem()
+//	   还原：Registry.FromProblem()、FromProblem()
+//
+// 错误链中每一个带错误码的错误（WithCode、WrapC）对应一个 Problem：
+//	type     Coder.Reference()，为空时省略（RFC 7807 规定省略时为 about:blank）
+//	title    使用错误的参数渲染的外部错误文本（见 template.go）
+//	status   Coder.HTTPStatus()
+//	detail   WithCode、WrapC 的错误信息
+//	code     Coder.Code()
+//	params   WithCodeParams、WrapCParams 的参数
+// 不带错误码的错误（例如 errors.New）只使用 unknown Coder 渲染，不输出其错误信息。
+// 使用同一个 Registry 渲染和还原时，NewProblem(FromProblem(p), p.Instance) 与 p 相同。
+// 还原没有在本地注册的错误码时使用 Problem 中的 title、status、type，因此也可以还原其他服务返回的 Problem。
+
+// ProblemContentType 是 Problem 文档的 Content-Type。
+const ProblemContentType = "application/problem+json"
+
+// Problem 是 RFC 7807 定义的 problem details 文档。
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Code 错误码
+	Code int `json:"code"`
+
+	// Cause 被包装的带错误码的错误
+	Cause *Problem `json:"cause,omitempty"`
+
+	// Errors 聚合错误（或其他包装多个错误的错误）中的每个错误
+	Errors []*Problem `json:"errors,omitempty"`
+
+	// Params 填充外部错误文本模板的参数
+	Params map[string]interface{} `json:"params,omitempty"`
+
+	// Extensions 其他扩展成员，不能覆盖上面的成员
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// problemMembers 是 Problem 中有对应字段的成员
+var problemMembers = NewString("type", "title", "status", "detail", "instance", "code", "cause", "errors", "params")
+
+// problem 用于避免 MarshalJSON、UnmarshalJSON 的递归调用
+type problem Problem
+
+// MarshalJSON 实现 json.Marshaler，将 Extensions 输出为顶层成员。
+func (p Problem) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	members := map[string]interface{}{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+
+	for name, v := range p.Extensions {
+		if !problemMembers.Has(name) {
+			members[name] = v
+		}
+	}
+
+	return json.Marshal(members)
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，未知的成员保存在 Extensions 中。
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var v problem
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var members map[string]interface{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	for name := range members {
+		if problemMembers.Has(name) {
+			delete(members, name)
+		}
+	}
+	if len(members) > 0 {
+		v.Extensions = members
+	}
+
+	*p = Problem(v)
+	return nil
+}
+
+// ParseProblem 解析 application/problem+json 文档。
+func ParseProblem(data []byte) (*Problem, error) {
+	var p Problem
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, Wrapf(err, "problem: invalid document: %v", err)
+	}
+
+	return &p, nil
+}
+
+// ===================================================================
+// NewProblem 使用 r 将 err 渲染为 Problem，instance 为发生错误的请求的 URI，可以为空。
+// 错误链中没有带错误码的错误时使用 unknown Coder，err 为 nil 时返回 nil。
+func (r *Registry) NewProblem(err error, instance string) *Problem {
+	if err == nil {
+		return nil
+	}
+	r.renderedErr(err)
+
+	s := r.load()
+
+	p := s.problem(err)
+	if p == nil {
+		p = s.codedProblem(s.unknown, "", nil)
+	}
+	p.Instance = instance
+
+	return p
+}
+
+// problem 沿错误链查找第一个带错误码的错误或包装多个错误的错误（聚合错误、Unwrap() []error）并渲染，
+// 都没有时返回 nil
+func (s *registryState) problem(err error) *Problem {
+	for e := err; e != nil; {
+		switch v := e.(type) {
+		case *withCode:
+			p := s.codedProblem(v.coderIn(s), v.err.Error(), v.params)
+			if v.cause != nil {
+				p.Cause = s.problem(v.cause)
+			}
+			return p
+		case Aggregate:
+			return s.joinedProblem(v.Errors())
+		case interface{ Unwrap() []error }:
+			return s.joinedProblem(v.Unwrap())
+		}
+
+		w, ok := e.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		e = w.Unwrap()
+	}
+
+	return nil
+}
+
+// joinedProblem 渲染包装了 errs 的错误，每个错误渲染为 Errors 中的一个 Problem
+func (s *registryState) joinedProblem(errs []error) *Problem {
+	p := s.codedProblem(s.unknown, "", nil)
+	for _, err := range errs {
+		ep := s.problem(err)
+		if ep == nil {
+			ep = s.codedProblem(s.unknown, "", nil)
+		}
+		p.Errors = append(p.Errors, ep)
+	}
+
+	return p
+}
+
+func (s *registryState) codedProblem(coder Coder, detail string, params Params) *Problem {
+	return &Problem{
+		Type:   coder.Reference(),
+		Title:  renderMessage(coder, params),
+		Status: coder.HTTPStatus(),
+		Detail: detail,
+		Code:   coder.Code(),
+		Params: copyParams(params),
+	}
+}
+
+// FromProblem 将 p 还原为由 r 渲染的错误：
+// 带有 Errors 的 Problem 还原为聚合错误，其他 Problem 还原为 WithCode（没有 Cause 时）或 WrapC。
+// 错误码没有在 r 中注册时，与 DecodeResponse 相同，使用 p 的 title、status、type 作为远程的 Coder。
+// 还原的错误不是新产生的错误，不会增加创建次数，也不会输出废弃警告。
+// p 为 nil 时返回 nil。
+func (r *Registry) FromProblem(p *Problem) error {
+	if p == nil {
+		return nil
+	}
+
+	if len(p.Errors) > 0 {
+		errs := make([]error, 0, len(p.Errors))
+		for _, ep := range p.Errors {
+			errs = append(errs, r.FromProblem(ep))
+		}
+
+		return NewAggregate(errs)
+	}
+
+	w := &withCode{
+		err:      fmt.Errorf("%s", p.Detail),
+		code:     p.Code,
+		cause:    r.FromProblem(p.Cause),
+		registry: r,
+		params:   copyParams(p.Params),
+		stack:    callers(),
+	}
+	if state := r.load(); !state.registered(state.resolve(p.Code)) {
+		w.coder = defaultCoder{C: p.Code, HTTP: p.Status, Ext: p.Title, Ref: p.Type}
+	}
+
+	return w
+}
+
+// ===================================================================
+// NewProblem 使用渲染 err 的 Registry 将 err 渲染为 Problem。
+func NewProblem(err error, instance string) *Problem {
+	return registryOf(err).NewProblem(err, instance)
+}
+
+// FromProblem 将 p 还原为由默认 Registry 渲染的错误。
+func FromProblem(p *Problem) error {
+	return defaultRegistry.FromProblem(p)
+}
@@ -0,0 +1,67 @@
+package errors
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFromProblemRoundTrip(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(140001, 404, "User not found", "https://example.com/errors/140001"))
+	r.MustRegister(NewCoder(140002, 500, "Database error", ""))
+
+	err := r.WrapC(r.WithCode(140002, "query users"), 140001, "get user %s", "alice")
+	p := r.NewProblem(err, "/users/alice")
+
+	if got := r.NewProblem(r.FromProblem(p), p.Instance); !reflect.DeepEqual(got, p) {
+		t.Errorf("NewProblem(FromProblem(p)) = %+v, want %+v", got, p)
+	}
+}
+
+func TestFromProblemUnregisteredCode(t *testing.T) {
+	remote := NewRegistry()
+	remote.MustRegister(NewCoder(140003, 409, "Name already taken", "https://example.com/errors/140003"))
+	p := remote.NewProblem(remote.WithCode(140003, "create user"), "")
+
+	r := NewRegistry()
+	r.EnableMetrics()
+	err := r.FromProblem(p)
+
+	coder := r.ParseCoder(err)
+	if coder.Code() != 140003 || coder.HTTPStatus() != 409 || coder.String() != "Name already taken" ||
+		coder.Reference() != "https://example.com/errors/140003" {
+		t.Errorf("ParseCoder() = %d %d %q %q", coder.Code(), coder.HTTPStatus(), coder.String(), coder.Reference())
+	}
+
+	if got := fmt.Sprintf("%s", err); got != "Name already taken" {
+		t.Errorf("%%s = %q", got)
+	}
+
+	if got := r.NewProblem(err, ""); !reflect.DeepEqual(got, p) {
+		t.Errorf("NewProblem(FromProblem(p)) = %+v, want %+v", got, p)
+	}
+
+	for _, m := range r.Metrics() {
+		if m.Created != 0 {
+			t.Errorf("FromProblem counted %d created errors for %d", m.Created, m.Code)
+		}
+	}
+}
+
+func TestFromProblemSkipsDeprecationWarning(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(140004, 400, "Invalid name", ""))
+	r.MustDeprecate(140004, 0)
+
+	warned := false
+	r.SetLogf(func(string, ...interface{}) { warned = true })
+
+	if err := r.FromProblem(&Problem{Code: 140004, Detail: "decoded"}); !r.IsCode(err, 140004) {
+		t.Errorf("FromProblem() = %v", err)
+	}
+
+	if warned {
+		t.Error("FromProblem emitted a deprecation warning")
+	}
+}
@@ -0,0 +1,503 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 文件内容：
+//	1、type Registry struct
+//		错误码注册表，每个 Registry 拥有独立的 code 命名空间。
+//		(1)创建：NewRegistry()
+//		(2)注册：Register()、MustRegister()、TryRegister()、RegisterCatalog()
+//		   注册时的校验：AddValidator()，见 validate.go
+//		(3)查询：Lookup()、ParseCoder()、IsCode()
+//		   Codes()、Range()、FindByHTTPStatus()、FindByMessage()、MarshalJSON()
+//		(4)删除：Unregister()，用于测试
+//		(5)未知错误码：SetUnknown()、Unknown()
+//		(6)冻结：Freeze()、Frozen()
+//		(7)创建绑定到该 Registry 的错误：WithCode()、WrapC()
+//		(8)多语言外部错误文本：见 locale.go
+//		(9)别名与废弃：见 alias.go
+//		(10)错误码注册的位置：Site()，见 site.go
+//		(11)计数：EnableMetrics()、Metrics()、MetricsHandler()，见 metrics.go
+//
+//	2、defaultRegistry
+//		包级别函数 Register、MustRegister、ParseCoder 等使用的默认注册表。
+//
+// 使用：
+//	多个子系统嵌入同一个进程，且错误码可能冲突时，
+//	每个子系统各自创建 Registry，并通过 Registry.WithCode / Registry.WrapC 创建错误，
+//	这样错误在格式化时会使用创建它的 Registry 进行渲染。
+
+// Registry 保存错误码到 Coder 的映射。
+//
+// 读操作（ParseCoder、IsCode、格式化等）无锁地读取当前状态的快照，
+// 写操作在 mux 保护下复制当前状态，修改副本后原子地替换（copy-on-write），
+// 因此注册与读取可以并发进行。调用 Freeze 之后，除重新加载目录以及 SetLogf 之外的写操作都会失败。
+type Registry struct {
+	mux    sync.Mutex   // 串行化写操作
+	state  atomic.Value // *registryState
+	frozen int32
+	warned sync.Map // 已经输出过废弃警告的错误码
+
+	// metrics 是否开启计数，counters 每个错误码和 HTTP 状态码的计数器，见 metrics.go
+	metrics     int32
+	countersMux sync.Mutex   // 串行化新增计数器
+	counters    atomic.Value // map[metricKey]*codeCounter，发布之后不再修改
+}
+
+// registryState 是 Registry 某一时刻的状态，发布之后不再修改
+type registryState struct {
+	codes   map[int]Coder
+	unknown Coder
+
+	// locales 每种语言的外部错误文本，defaultLocale 为回退链最后使用的语言
+	locales       map[string]map[int]string
+	defaultLocale string
+
+	// aliases 旧错误码到新错误码的映射，deprecated 废弃的错误码到替代错误码的映射，见 alias.go
+	aliases    map[int]int
+	deprecated map[int]int
+
+	// sites 每个错误码（包括别名）注册的位置，见 site.go
+	sites map[int]Site
+
+	// logf 输出废弃警告
+	logf func(format string, args ...interface{})
+
+	// validators 注册时运行的校验，见 validate.go
+	validators []Validator
+}
+
+// clone 返回 s 的浅拷贝，codes 会被复制，locales 的内层 map 需要修改时再复制
+func (s *registryState) clone() *registryState {
+	next := *s
+
+	next.codes = make(map[int]Coder, len(s.codes)+1)
+	for code, coder := range s.codes {
+		next.codes[code] = coder
+	}
+
+	next.locales = make(map[string]map[int]string, len(s.locales))
+	for locale, messages := range s.locales {
+		next.locales[locale] = messages
+	}
+
+	next.aliases = copyIntMap(s.aliases)
+	next.deprecated = copyIntMap(s.deprecated)
+	next.sites = copySites(s.sites)
+
+	return &next
+}
+
+// defaultRegistry 是包级别函数使用的注册表。
+var defaultRegistry = NewRegistry()
+
+// NewRegistry 创建一个新的 Registry，
+// 其未知错误码 Coder 为包预定义的 unknownCoder。
+func NewRegistry() *Registry {
+	r := &Registry{}
+	r.state.Store(&registryState{
+		codes:   map[int]Coder{unknownCoder.Code(): unknownCoder},
+		unknown: unknownCoder,
+	})
+
+	return r
+}
+
+// DefaultRegistry 返回包级别函数使用的注册表。
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// load 返回 r 当前状态的快照
+func (r *Registry) load() *registryState {
+	return r.state.Load().(*registryState)
+}
+
+// update 在写锁保护下复制 r 的当前状态，fn 修改副本成功后将其发布。
+// r 已经冻结时返回错误，fn 返回错误时 r 保持不变。
+func (r *Registry) update(fn func(next *registryState) error) error {
+	return r.apply(true, fn)
+}
+
+// apply 实现 update，checkFrozen 为 false 时允许修改已经冻结的 r，仅用于重新加载目录。
+func (r *Registry) apply(checkFrozen bool, fn func(next *registryState) error) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if checkFrozen && r.Frozen() {
+		return errFrozen
+	}
+
+	next := r.load().clone()
+	if err := fn(next); err != nil {
+		return err
+	}
+	r.state.Store(next)
+
+	return nil
+}
+
+// mustUpdate 与 update 相同，失败时将会引发 panic。
+func (r *Registry) mustUpdate(fn func(next *registryState) error) {
+	if err := r.update(fn); err != nil {
+		panic(err.Error())
+	}
+}
+
+// errFrozen 是冻结之后修改 Registry 返回的错误
+var errFrozen = New("registry is frozen")
+
+// Freeze 冻结 r，之后 Register、MustRegister、SetUnknown 等修改操作都会引发 panic，
+// 返回 error 的修改操作（例如 RegisterCatalog）返回错误。
+// 通常在所有 init 注册完成、开始处理请求之前调用。
+//
+// 冻结只保证错误码的集合不再变化，Reload、ReloadFile 修改已注册错误码的外部错误文本、
+// HTTP 状态码以及 reference 文档不受冻结的限制（见 reload.go），SetLogf 也可以在冻结之后调用。
+func (r *Registry) Freeze() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	atomic.StoreInt32(&r.frozen, 1)
+}
+
+// Frozen 报告 r 是否已经冻结。
+func (r *Registry) Frozen() bool {
+	return atomic.LoadInt32(&r.frozen) == 1
+}
+
+// SetUnknown 设置该 Registry 在错误码未注册时返回的 Coder。
+// coder 为 nil 时恢复为包预定义的 unknownCoder。
+func (r *Registry) SetUnknown(coder Coder) {
+	if coder == nil {
+		coder = unknownCoder
+	}
+
+	r.mustUpdate(func(next *registryState) error {
+		delete(next.codes, next.unknown.Code())
+		next.unknown = coder
+		next.codes[coder.Code()] = coder
+
+		return nil
+	})
+}
+
+// Unknown 返回该 Registry 在错误码未注册时返回的 Coder。
+func (r *Registry) Unknown() Coder {
+	return r.load().unknown
+}
+
+// Register 注册一个用户定义的错误码
+// 它将会覆盖已存在的相同 code，校验失败时将会引发 panic
+func (r *Registry) Register(coder Coder) {
+	site := callerSite()
+	r.mustUpdate(func(next *registryState) error {
+		next.checkReserved(coder)
+		if err := next.validate(coder); err != nil {
+			return err
+		}
+		delete(next.aliases, coder.Code())
+		next.codes[coder.Code()] = coder
+		next.sites[coder.Code()] = site
+
+		return nil
+	})
+}
+
+// MustRegister 注册用户定义的错误码
+// 当相同的 Code 已经存在或者校验失败时，将会引发 panic，panic 信息包含两次注册的位置
+//
+// 每次注册都会复制一次注册表的状态（见 Registry），注册大量错误码时应该在一次调用中注册，例如：
+//
+//	MustRegister(ErrUserNotFound, ErrUserExists, ErrInvalidName)
+//
+// 一次调用中的任意一个 Coder 注册失败时，不注册其中的任何 Coder。
+func (r *Registry) MustRegister(coders ...Coder) {
+	if err := r.registerAll(coders, repeatSite(callerSite(), len(coders))); err != nil {
+		panic(err.Error())
+	}
+}
+
+// TryRegister 与 MustRegister 相同，注册失败（保留的错误码、相同的 Code 已经存在、校验失败、
+// r 已经冻结）时返回错误而不是引发 panic。
+func (r *Registry) TryRegister(coders ...Coder) error {
+	return r.registerAll(coders, repeatSite(callerSite(), len(coders)))
+}
+
+// repeatSite 返回包含 n 个 site 的切片
+func repeatSite(site Site, n int) []Site {
+	sites := make([]Site, n)
+	for i := range sites {
+		sites[i] = site
+	}
+
+	return sites
+}
+
+// registerAll 注册一组 Coder，sites[i] 为 coders[i] 注册的位置，
+// 任意一个 code 已经存在时不注册任何 Coder 并返回错误。
+func (r *Registry) registerAll(coders []Coder, sites []Site) error {
+	return r.update(func(next *registryState) error {
+		return next.registerAll(coders, sites)
+	})
+}
+
+// registerAll 在 s 中注册一组 Coder，任意一个 code 已经存在（包括在这一组中重复）或者校验失败时
+// 不注册任何 Coder 并返回错误。
+func (s *registryState) registerAll(coders []Coder, sites []Site) error {
+	var errs []error
+	batch := make(map[int]int, len(coders))
+	for i, coder := range coders {
+		if err := s.reserved(coder); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := s.checkExist(coder.Code(), sites[i]); err != nil {
+			errs = append(errs, err)
+		}
+
+		if first, ok := batch[coder.Code()]; ok {
+			errs = append(errs, Errorf("code: %d already exist, registered at %s, registering again at %s", coder.Code(), sites[first], sites[i]))
+		} else {
+			batch[coder.Code()] = i
+		}
+
+		if err := s.validate(coder); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if agg := NewAggregate(errs); agg != nil {
+		return agg
+	}
+
+	for i, coder := range coders {
+		s.codes[coder.Code()] = coder
+		s.sites[coder.Code()] = sites[i]
+	}
+
+	return nil
+}
+
+// checkExist 检查 code 是否已经注册为错误码或者别名，site 为这次注册的位置，
+// 返回的错误包含两次注册的位置
+func (s *registryState) checkExist(code int, site Site) error {
+	if _, ok := s.codes[code]; ok {
+		return Errorf("code: %d already exist, registered at %s, registering again at %s", code, s.sites[code], site)
+	}
+
+	if target, ok := s.aliases[code]; ok {
+		return Errorf("code: %d already exist as an alias of %d, registered at %s, registering again at %s", code, target, s.sites[code], site)
+	}
+
+	return nil
+}
+
+// checkReserved 检查 coder 是否使用了保留的错误码，使用时将会引发 panic。
+func (s *registryState) checkReserved(coder Coder) {
+	if err := s.reserved(coder); err != nil {
+		panic(err.Error())
+	}
+}
+
+// reserved 检查 coder 是否使用了保留的错误码。
+func (s *registryState) reserved(coder Coder) error {
+	if coder.Code() == 0 {
+		// 0 被该 error 包保留为 unknownCode 错误码
+		return New("code `0` is reserved by `github.com/tiandh987/errors` as unknownCode error code")
+	}
+
+	if coder.Code() == s.unknown.Code() {
+		return Errorf("code `%d` is reserved by registry as unknownCode error code", coder.Code())
+	}
+
+	return nil
+}
+
+// Lookup 返回 code 对应的 Coder，code 是别名时返回新错误码的 Coder，code 未注册时 ok 为 false。
+func (r *Registry) Lookup(code int) (coder Coder, ok bool) {
+	state := r.load()
+	coder, ok = state.codes[state.resolve(code)]
+	return
+}
+
+// Unregister 删除 code 的注册以及 code 的别名、废弃标记，返回 code 是否曾经注册过，主要用于测试。
+// unknown Coder 不能被删除。
+func (r *Registry) Unregister(code int) bool {
+	var ok bool
+	r.mustUpdate(func(next *registryState) error {
+		if code == next.unknown.Code() {
+			return nil
+		}
+
+		_, ok = next.codes[code]
+		delete(next.codes, code)
+		delete(next.aliases, code)
+		delete(next.deprecated, code)
+		delete(next.sites, code)
+
+		return nil
+	})
+
+	return ok
+}
+
+// Codes 返回所有已注册 Coder 的快照，按照错误码排序，不包含 unknown Coder。
+func (r *Registry) Codes() []Coder {
+	return r.coders()
+}
+
+// Range 按照错误码顺序对每个已注册的 Coder 调用 fn，fn 返回 false 时停止遍历。
+// fn 遍历的是调用 Range 时的快照，可以在 fn 中注册或删除错误码。
+func (r *Registry) Range(fn func(coder Coder) bool) {
+	for _, coder := range r.coders() {
+		if !fn(coder) {
+			return
+		}
+	}
+}
+
+// FindByHTTPStatus 返回 HTTP 状态码为 status 的所有 Coder，按照错误码排序。
+func (r *Registry) FindByHTTPStatus(status int) []Coder {
+	return r.find(func(coder Coder) bool {
+		return coder.HTTPStatus() == status
+	})
+}
+
+// FindByMessage 返回外部错误文本包含 substr 的所有 Coder，按照错误码排序，不区分大小写。
+func (r *Registry) FindByMessage(substr string) []Coder {
+	substr = strings.ToLower(substr)
+	return r.find(func(coder Coder) bool {
+		return strings.Contains(strings.ToLower(coder.String()), substr)
+	})
+}
+
+// find 返回所有满足 match 的 Coder
+func (r *Registry) find(match func(coder Coder) bool) []Coder {
+	var coders []Coder
+	r.Range(func(coder Coder) bool {
+		if match(coder) {
+			coders = append(coders, coder)
+		}
+		return true
+	})
+
+	return coders
+}
+
+// MarshalJSON 将所有已注册的错误码导出为按照错误码排序的 JSON 数组，
+// 格式与 JSON 格式的错误码目录相同，可以使用 ParseCatalogJSON 解析。
+func (r *Registry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Catalog())
+}
+
+// coders 返回所有已注册的 Coder，按照错误码排序，不包含 unknown Coder。
+func (r *Registry) coders() []Coder {
+	state := r.load()
+
+	coders := make([]Coder, 0, len(state.codes))
+	for code, coder := range state.codes {
+		if code == state.unknown.Code() {
+			continue
+		}
+		coders = append(coders, coder)
+	}
+
+	sort.Slice(coders, func(i, j int) bool {
+		return coders[i].Code() < coders[j].Code()
+	})
+
+	return coders
+}
+
+// coder 返回 code 对应的 Coder，code 未注册时返回 unknown Coder。
+func (r *Registry) coder(code int) Coder {
+	return r.load().coder(code)
+}
+
+// coder 返回 code 对应的 Coder，code 是别名时返回新错误码的 Coder，code 未注册时返回 unknown Coder。
+func (s *registryState) coder(code int) Coder {
+	if coder, ok := s.codes[s.resolve(code)]; ok {
+		return coder
+	}
+
+	return s.unknown
+}
+
+// snapshots 记录一次操作中用到的每个 Registry 的状态快照，
+// 保证一次格式化中错误链的每一层看到的都是同一份数据。
+type snapshots map[*Registry]*registryState
+
+// of 返回 r 的快照，第一次调用时读取 r 的当前状态
+func (s snapshots) of(r *Registry) *registryState {
+	if state, ok := s[r]; ok {
+		return state
+	}
+
+	state := r.load()
+	s[r] = state
+	return state
+}
+
+// ParseCoder 使用该 Registry 将 err 的错误树中第一个 withCode 错误解析为 Coder。
+// nil error 将直接返回 nil，
+// 不包含 withCode 的错误以及未注册的错误码将被解析为 unknown Coder。
+func (r *Registry) ParseCoder(err error) Coder {
+	if err == nil {
+		return nil
+	}
+
+	if v, ok := firstCoded(err); ok {
+		return v.coderIn(r.load())
+	}
+
+	return r.Unknown()
+}
+
+// IsCode 报告错误树中是否包含由该 Registry 渲染的给定错误代码，别名与新错误码视为相同，
+// 错误树中其他包的包装错误（实现了 Unwrap() error 或 Unwrap() []error）以及聚合错误也会被展开。
+func (r *Registry) IsCode(err error, code int) bool {
+	state := r.load()
+	code = state.resolve(code)
+
+	return walk(err, func(e error) bool {
+		v, ok := e.(*withCode)
+		return ok && v.renderer() == r && state.resolve(v.code) == code
+	})
+}
+
+// WithCode 创建新的 withCode 类型的错误，该错误由 r 渲染。
+func (r *Registry) WithCode(code int, format string, args ...interface{}) error {
+	r.created(code)
+
+	return &withCode{
+		err:      fmt.Errorf(format, args...),
+		code:     code,
+		registry: r,
+		stack:    callers(),
+	}
+}
+
+// WrapC 使用错误码包装 err，返回的错误由 r 渲染。
+// 如果 err 为 nil，WrapC 返回 nil。
+func (r *Registry) WrapC(err error, code int, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	r.created(code)
+
+	return &withCode{
+		err:      fmt.Errorf(format, args...),
+		code:     code,
+		cause:    err,
+		registry: r,
+		stack:    callers(),
+	}
+}
@@ -0,0 +1,247 @@
+package errors
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// newTestRegistry 返回注册了 n 个错误码（100001 ~ 100000+n）的 Registry
+func newTestRegistry(n int) *Registry {
+	r := NewRegistry()
+	for i := 1; i <= n; i++ {
+		r.MustRegister(NewCoder(100000+i, 400, fmt.Sprintf("Error %d", i), ""))
+	}
+
+	return r
+}
+
+func TestRegistryIsolation(t *testing.T) {
+	a, b := NewRegistry(), NewRegistry()
+	a.MustRegister(NewCoder(100001, 404, "Not found", ""))
+	b.MustRegister(NewCoder(100001, 409, "Conflict", ""))
+
+	if got := a.ParseCoder(a.WithCode(100001, "a")).HTTPStatus(); got != 404 {
+		t.Errorf("a: HTTPStatus() = %d, want 404", got)
+	}
+
+	if got := b.ParseCoder(b.WithCode(100001, "b")).HTTPStatus(); got != 409 {
+		t.Errorf("b: HTTPStatus() = %d, want 409", got)
+	}
+
+	if got := fmt.Sprintf("%s", b.WithCode(100001, "b")); got != "Conflict" {
+		t.Errorf("b error renders %q, want Conflict", got)
+	}
+
+	if a.IsCode(b.WithCode(100001, "b"), 100001) {
+		t.Error("a.IsCode matched an error rendered by b")
+	}
+}
+
+func TestRegistryFreeze(t *testing.T) {
+	r := newTestRegistry(1)
+	r.Freeze()
+
+	if !r.Frozen() {
+		t.Fatal("Frozen() = false after Freeze")
+	}
+
+	if err := r.TryRegister(NewCoder(100002, 400, "Late", "")); err == nil {
+		t.Error("TryRegister succeeded after Freeze")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("MustRegister did not panic after Freeze")
+			}
+		}()
+		r.MustRegister(NewCoder(100003, 400, "Late", ""))
+	}()
+
+	if _, ok := r.Lookup(100001); !ok {
+		t.Error("Lookup lost a code registered before Freeze")
+	}
+}
+
+func TestRegisterBatch(t *testing.T) {
+	r := newTestRegistry(1)
+
+	if err := r.TryRegister(NewCoder(100002, 400, "B", ""), NewCoder(100003, 400, "C", "")); err != nil {
+		t.Fatalf("TryRegister() = %v", err)
+	}
+
+	// 任意一个 Coder 注册失败时不注册其中的任何 Coder
+	for _, batch := range [][]Coder{
+		{NewCoder(100004, 400, "D", ""), NewCoder(100001, 400, "Registered", "")},
+		{NewCoder(100005, 400, "E", ""), NewCoder(100005, 400, "E again", "")},
+	} {
+		if err := r.TryRegister(batch...); err == nil {
+			t.Errorf("TryRegister(%d, %d) succeeded", batch[0].Code(), batch[1].Code())
+		}
+		if _, ok := r.Lookup(batch[0].Code()); ok {
+			t.Errorf("failed batch registered %d", batch[0].Code())
+		}
+	}
+
+	if got := len(r.Codes()); got != 3 {
+		t.Errorf("len(Codes()) = %d, want 3", got)
+	}
+}
+
+// TestConcurrentRegisterAndFormat 在注册新错误码的同时并发地解析、格式化错误，使用 -race 运行。
+func TestConcurrentRegisterAndFormat(t *testing.T) {
+	const codes = 200
+	r := newTestRegistry(codes)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := codes + 1; i <= 2*codes; i++ {
+			r.MustRegister(NewCoder(100000+i, 500, fmt.Sprintf("Late error %d", i), ""))
+		}
+	}()
+
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 1; i <= 2*codes; i++ {
+				err := r.WithCode(100000+i, "goroutine %d", g)
+
+				coder := r.ParseCoder(err)
+				if i <= codes && coder.Code() != 100000+i {
+					t.Errorf("ParseCoder() = %d, want %d", coder.Code(), 100000+i)
+				}
+
+				_ = fmt.Sprintf("%s", err)
+				_ = fmt.Sprintf("%#+v", err)
+				_ = r.IsCode(err, 100000+i)
+				_, _ = r.Lookup(100000 + i)
+			}
+		}(g)
+	}
+
+	wg.Wait()
+
+	if got := len(r.Codes()); got != 2*codes {
+		t.Errorf("len(Codes()) = %d, want %d", got, 2*codes)
+	}
+}
+
+// TestConcurrentFreeze 在并发注册的同时冻结，冻结之后的注册都必须失败，成功的注册都必须可以查到。
+func TestConcurrentFreeze(t *testing.T) {
+	const (
+		workers = 4
+		perWork = 100
+	)
+	r := NewRegistry()
+
+	var (
+		wg        sync.WaitGroup
+		mux       sync.Mutex
+		succeeded []int
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 1; i <= perWork; i++ {
+				code := 100000 + w*perWork + i
+				if err := r.TryRegister(NewCoder(code, 400, "Error", "")); err == nil {
+					mux.Lock()
+					succeeded = append(succeeded, code)
+					mux.Unlock()
+				}
+			}
+		}(w)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.Freeze()
+	}()
+
+	wg.Wait()
+
+	if err := r.TryRegister(NewCoder(200001, 400, "Error", "")); err == nil {
+		t.Error("TryRegister succeeded after Freeze")
+	}
+
+	if got := len(r.Codes()); got != len(succeeded) {
+		t.Errorf("len(Codes()) = %d, want %d successful registrations", got, len(succeeded))
+	}
+
+	for _, code := range succeeded {
+		if _, ok := r.Lookup(code); !ok {
+			t.Errorf("Lookup(%d) failed after a successful registration", code)
+		}
+	}
+}
+
+// TestConcurrentLookupAfterFreeze 冻结之后的读操作不需要任何同步。
+func TestConcurrentLookupAfterFreeze(t *testing.T) {
+	r := newTestRegistry(100)
+	r.Freeze()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= 100; i++ {
+				if coder, ok := r.Lookup(100000 + i); !ok || coder.Code() != 100000+i {
+					t.Errorf("Lookup(%d) = %v, %v", 100000+i, coder, ok)
+				}
+				_ = r.Codes()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func BenchmarkParseCoder(b *testing.B) {
+	r := newTestRegistry(1000)
+	r.Freeze()
+	err := r.WrapC(New("root cause"), 100001, "wrapped")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = r.ParseCoder(err)
+		}
+	})
+}
+
+func BenchmarkFormatString(b *testing.B) {
+	r := newTestRegistry(1000)
+	r.Freeze()
+	err := r.WrapC(New("root cause"), 100001, "wrapped")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = fmt.Sprintf("%s", err)
+		}
+	})
+}
+
+func BenchmarkFormatJSONTrace(b *testing.B) {
+	r := newTestRegistry(1000)
+	r.Freeze()
+	err := r.WrapC(New("root cause"), 100001, "wrapped")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = fmt.Sprintf("%#+v", err)
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package errors
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 文件内容：
+//	1、重新加载外部错误文本以及 HTTP 状态码
+//		Registry.Reload()、Registry.ReloadFile()
+//
+//	2、触发重新加载
+//		Registry.WatchFile()      轮询目录文件的修改时间
+//		Registry.ReloadOnSignal() 收到信号（默认为 SIGHUP）时重新加载
+//
+// 重新加载只会修改已经注册的错误码的 HTTP 状态码、外部错误文本以及 reference 文档，
+// 不能新增错误码，因此 Freeze 不会阻止重新加载：冻结保证错误码的集合不再变化，
+// 而外部错误文本在冻结之后仍然可以修改。
+// 目录中只要有一条定义不合法（格式错误、重复、未注册的错误码），就不会修改任何数据，
+// 原来的数据保持不变。新数据以快照的形式原子地替换旧数据，
+// 正在进行的格式化看到的要么全是旧数据，要么全是新数据。
+
+// reloadedCoder 使用重新加载的数据替代 Coder 的 HTTPStatus、String、Reference，
+// 其他可选接口（RPCCoder、LocalizedCoder、MetaCoder、HeaderCoder、TemplateCoder）转发给原来的 Coder。
+type reloadedCoder struct {
+	Coder
+	http int
+	ext  string
+	ref  string
+}
+
+func (coder reloadedCoder) HTTPStatus() int {
+	return coder.http
+}
+
+func (coder reloadedCoder) String() string {
+	return coder.ext
+}
+
+func (coder reloadedCoder) Reference() string {
+	return coder.ref
+}
+
+// RPCStatus 优先使用原来 Coder 声明的 RPC 状态码，否则由重新加载的 HTTP 状态码推导。
+func (coder reloadedCoder) RPCStatus() RPCCode {
+	if _, ok := coder.Coder.(defaultCoder); !ok {
+		if rc, ok := coder.Coder.(RPCCoder); ok {
+			return rc.RPCStatus()
+		}
+	}
+
+	return RPCCodeFromHTTP(coder.http)
+}
+
+// LocalizedString 转发给原来的 Coder。
+func (coder reloadedCoder) LocalizedString(locale string) (string, bool) {
+	if lc, ok := coder.Coder.(LocalizedCoder); ok {
+		return lc.LocalizedString(locale)
+	}
+
+	return "", false
+}
+
+// Template 转发给原来的 Coder。
+func (coder reloadedCoder) Template() string {
+	return templateOf(coder.Coder)
+}
+
+func (coder reloadedCoder) Severity() Severity {
+	return coderMeta(coder.Coder).Severity()
+}
+
+func (coder reloadedCoder) Owner() string {
+	return coderMeta(coder.Coder).Owner()
+}
+
+func (coder reloadedCoder) Remediation() string {
+	return coderMeta(coder.Coder).Remediation()
+}
+
+func (coder reloadedCoder) Description() string {
+	return coderMeta(coder.Coder).Description()
+}
+
+// Header 转发给原来的 Coder。
+func (coder reloadedCoder) Header() http.Header {
+	if hc, ok := coder.Coder.(HeaderCoder); ok {
+		return hc.Header()
+	}
+
+	return nil
+}
+
+// reloaded 返回使用 e 中的数据替代 coder 的新 Coder
+func reloaded(coder Coder, e CatalogEntry) Coder {
+	switch c := coder.(type) {
+	case defaultCoder:
+		return defaultCoder{C: c.C, HTTP: e.HTTP, Ext: e.Message, Ref: e.Reference}
+	case templateCoder:
+		// 重新加载的外部错误文本替代参数缺失时使用的文本，模板保持不变
+		return templateCoder{defaultCoder: defaultCoder{C: c.C, HTTP: e.HTTP, Ext: e.Message, Ref: e.Reference}, tmpl: c.tmpl}
+	case RichCoder:
+		// 目录中的 reference 与模板展开的结果相同时（例如目录由 Registry.Catalog() 导出）保留模板，
+		// 否则将其作为新的模板，元数据保持不变
+		if e.Reference != c.Reference() {
+			c.Ref = e.Reference
+		}
+		c.HTTP, c.Ext = e.HTTP, e.Message
+		return c
+	case reloadedCoder:
+		coder = c.Coder
+	}
+
+	return reloadedCoder{Coder: coder, http: e.HTTP, ext: e.Message, ref: e.Reference}
+}
+
+// ===================================================================
+// Reload 使用目录中的定义原子地替换已注册错误码的 HTTP 状态码、外部错误文本以及 reference 文档。
+// 任意一条定义不合法时返回错误，r 保持不变。r 已经冻结时仍然可以重新加载。
+func (r *Registry) Reload(entries []CatalogEntry) error {
+	return r.apply(false, func(next *registryState) error {
+		var errs []error
+
+		seen := map[int]CatalogEntry{}
+		for _, e := range entries {
+			if err := e.validate(); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			if prev, ok := seen[e.Code]; ok {
+				errs = append(errs, Errorf("catalog: %s: duplicate code %d, first defined at %s", e.where(), e.Code, prev.where()))
+				continue
+			}
+			seen[e.Code] = e
+
+			coder, ok := next.codes[e.Code]
+			if !ok || e.Code == next.unknown.Code() {
+				errs = append(errs, Errorf("catalog: %s: code %d is not registered", e.where(), e.Code))
+				continue
+			}
+
+			coder = reloaded(coder, e)
+			if err := next.validate(coder); err != nil {
+				errs = append(errs, WithMessagef(err, "catalog: %s: %v", e.where(), err))
+				continue
+			}
+			next.codes[e.Code] = coder
+		}
+
+		return NewAggregate(errs)
+	})
+}
+
+// ReloadFile 读取 path 指定的目录文件并调用 Reload，
+// 扩展名为 .json 的文件按照 JSON 格式解析，其他文件按照文本格式解析。
+func (r *Registry) ReloadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Wrapf(err, "catalog: read %s failed: %v", path, err)
+	}
+
+	entries, err := ParseCatalog(data, catalogFormatOf(path))
+	if err != nil {
+		return WithMessagef(err, "%s: %v", path, err)
+	}
+
+	if err := r.Reload(entries); err != nil {
+		return WithMessagef(err, "%s: %v", path, err)
+	}
+
+	return nil
+}
+
+// defaultWatchInterval 是 WatchFile 的 interval 不是正数时使用的检查间隔
+const defaultWatchInterval = time.Second
+
+// WatchFile 每隔 interval 检查一次 path 的修改时间，发生变化时调用 ReloadFile，
+// interval 不是正数时每秒检查一次。
+// 重新加载失败时调用 onError（可以为 nil），旧数据保持不变。
+// 返回的 stop 函数用于停止检查，可以多次调用。
+func (r *Registry) WatchFile(path string, interval time.Duration, onError func(error)) (stop func()) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	var modTime time.Time
+	if fi, err := os.Stat(path); err == nil {
+		modTime = fi.ModTime()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			fi, err := os.Stat(path)
+			if err != nil {
+				notifyError(onError, Wrapf(err, "catalog: stat %s failed: %v", path, err))
+				continue
+			}
+
+			if fi.ModTime().Equal(modTime) {
+				continue
+			}
+			modTime = fi.ModTime()
+
+			notifyError(onError, r.ReloadFile(path))
+		}
+	}()
+
+	return stopFunc(done)
+}
+
+// ReloadOnSignal 每次收到 sig 中的信号时调用 ReloadFile，例如：
+//
+//	stop := r.ReloadOnSignal("codes.json", onError, syscall.SIGHUP)
+//	defer stop()
+//
+// 重新加载失败时调用 onError（可以为 nil），旧数据保持不变。
+// sig 为空时监听 SIGHUP，而不是像 signal.Notify 那样监听所有信号，
+// 否则 SIGINT、SIGTERM 等信号也会触发重新加载，进程不再因为这些信号而退出。
+// 返回的 stop 函数用于停止监听，可以多次调用。
+func (r *Registry) ReloadOnSignal(path string, onError func(error), sig ...os.Signal) (stop func()) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig...)
+
+	done := make(chan struct{})
+	go func() {
+		defer signal.Stop(ch)
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ch:
+				notifyError(onError, r.ReloadFile(path))
+			}
+		}
+	}()
+
+	return stopFunc(done)
+}
+
+// notifyError 在 err 不为 nil 时调用 onError
+func notifyError(onError func(error), err error) {
+	if err != nil && onError != nil {
+		onError(err)
+	}
+}
+
+// stopFunc 返回关闭 done 的函数，可以多次调用
+func stopFunc(done chan struct{}) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package errors
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(110001, 404, "User not found", ""))
+	r.MustRegister(NewCoder(110002, 400, "Invalid name", ""))
+
+	err := r.Reload([]CatalogEntry{
+		{Code: 110001, HTTP: 410, Message: "User is gone", Reference: "https://example.com/errors#code-110001"},
+	})
+	if err != nil {
+		t.Fatalf("Reload() = %v", err)
+	}
+
+	coder, _ := r.Lookup(110001)
+	if coder.HTTPStatus() != 410 || coder.String() != "User is gone" || coder.Reference() != "https://example.com/errors#code-110001" {
+		t.Errorf("reloaded coder = %d %q %q", coder.HTTPStatus(), coder.String(), coder.Reference())
+	}
+
+	if got := fmt.Sprintf("%s", r.WithCode(110001, "alice")); got != "User is gone" {
+		t.Errorf("reloaded error renders %q", got)
+	}
+
+	// 没有出现在目录中的错误码保持不变
+	if coder, _ := r.Lookup(110002); coder.String() != "Invalid name" {
+		t.Errorf("untouched coder = %q", coder.String())
+	}
+}
+
+func TestReloadFailureKeepsOldData(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(110001, 404, "User not found", ""))
+	r.MustRegister(NewCoder(110002, 400, "Invalid name", ""))
+
+	err := r.Reload([]CatalogEntry{
+		{Code: 110001, HTTP: 410, Message: "User is gone"},
+		{Code: 110003, HTTP: 400, Message: "Not registered"},
+		{Code: 110002, HTTP: 999, Message: "Bad status"},
+	})
+	if err == nil {
+		t.Fatal("Reload() succeeded with invalid entries")
+	}
+
+	for _, want := range []string{"code 110003 is not registered", "invalid http status 999"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Reload() error %q does not contain %q", err, want)
+		}
+	}
+
+	if coder, _ := r.Lookup(110001); coder.HTTPStatus() != 404 || coder.String() != "User not found" {
+		t.Errorf("failed reload modified coder: %d %q", coder.HTTPStatus(), coder.String())
+	}
+}
+
+func TestReloadValidationFailureReportsReason(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(110001, 404, "User not found", ""))
+	r.AddValidator(NoTrailingPeriod())
+
+	err := r.Reload([]CatalogEntry{{Code: 110001, HTTP: 404, Message: "User not found."}})
+	if err == nil {
+		t.Fatal("Reload() succeeded with a message rejected by a validator")
+	}
+
+	if !strings.Contains(err.Error(), "ends with a period") {
+		t.Errorf("Reload() error %q does not include the validation failure", err)
+	}
+}
+
+func TestReloadAfterFreeze(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(110001, 404, "User not found", ""))
+	r.Freeze()
+
+	// 冻结之后不能注册新的错误码，但是可以重新加载已注册的错误码
+	if err := r.TryRegister(NewCoder(110002, 400, "Late", "")); err == nil {
+		t.Error("TryRegister succeeded after Freeze")
+	}
+
+	if err := r.Reload([]CatalogEntry{{Code: 110001, HTTP: 404, Message: "No such user"}}); err != nil {
+		t.Fatalf("Reload() after Freeze = %v", err)
+	}
+
+	if coder, _ := r.Lookup(110001); coder.String() != "No such user" {
+		t.Errorf("Reload() after Freeze did not apply: %q", coder.String())
+	}
+
+	// 重新加载也不能新增错误码
+	if err := r.Reload([]CatalogEntry{{Code: 110002, HTTP: 400, Message: "Late"}}); err == nil {
+		t.Error("Reload() registered a new code after Freeze")
+	}
+}
+
+func TestReloadRichCoder(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(RichCoder{
+		C:    110001,
+		HTTP: 404,
+		Ext:  "User not found",
+		Ref:  "https://example.com/errors/{code}",
+		Sev:  SeverityWarning,
+		Team: "identity",
+	})
+
+	// 由 Catalog() 导出的目录包含展开后的 reference，重新加载时保留模板
+	entries := r.Catalog()
+	entries[0].Message = "No such user"
+	if err := r.Reload(entries); err != nil {
+		t.Fatalf("Reload() = %v", err)
+	}
+
+	coder, _ := r.Lookup(110001)
+	rc, ok := coder.(RichCoder)
+	if !ok {
+		t.Fatalf("reloaded coder is %T, want RichCoder", coder)
+	}
+
+	if rc.Ref != "https://example.com/errors/{code}" || rc.Reference() != "https://example.com/errors/110001" {
+		t.Errorf("reference template = %q, reference = %q", rc.Ref, rc.Reference())
+	}
+
+	if rc.String() != "No such user" || rc.Severity() != SeverityWarning || rc.Owner() != "identity" {
+		t.Errorf("reloaded RichCoder = %+v", rc)
+	}
+
+	// 新的 reference 作为模板使用
+	entries[0].Reference = "https://docs.example.com/e/{code}"
+	if err := r.Reload(entries); err != nil {
+		t.Fatalf("Reload() = %v", err)
+	}
+
+	if coder, _ := r.Lookup(110001); coder.Reference() != "https://docs.example.com/e/110001" {
+		t.Errorf("reference = %q", coder.Reference())
+	}
+}
+
+func TestReloadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "codes.txt")
+
+	r := NewRegistry()
+	r.MustRegister(NewCoder(110001, 404, "User not found", ""))
+
+	if err := ioutil.WriteFile(path, []byte("110001 | 404 | No such user\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.ReloadFile(path); err != nil {
+		t.Fatalf("ReloadFile() = %v", err)
+	}
+
+	if coder, _ := r.Lookup(110001); coder.String() != "No such user" {
+		t.Errorf("ReloadFile() did not apply: %q", coder.String())
+	}
+
+	err := r.ReloadFile(filepath.Join(dir, "missing.txt"))
+	if err == nil || !strings.Contains(err.Error(), "missing.txt") || !strings.Contains(err.Error(), "no such file") {
+		t.Errorf("ReloadFile(missing) = %v", err)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "codes.txt")
+	if err := ioutil.WriteFile(path, []byte("110001 | 404 | User not found\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry()
+	r.MustRegister(NewCoder(110001, 404, "User not found", ""))
+
+	errs := make(chan error, 10)
+	stop := r.WatchFile(path, 10*time.Millisecond, func(err error) { errs <- err })
+	defer stop()
+
+	if err := ioutil.WriteFile(path, []byte("110001 | 404 | No such user\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// 保证修改时间发生变化
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if coder, _ := r.Lookup(110001); coder.String() == "No such user" {
+			break
+		}
+
+		select {
+		case err := <-errs:
+			t.Fatalf("WatchFile reported %v", err)
+		default:
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("WatchFile did not reload the changed file")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stop()
+	stop()
+}
+
+func TestWatchFileNonPositiveInterval(t *testing.T) {
+	r := NewRegistry()
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		stop := r.WatchFile(filepath.Join(t.TempDir(), "codes.txt"), interval, nil)
+		stop()
+	}
+}
+
+func TestReloadOnSignalDefaultsToSIGHUP(t *testing.T) {
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// 测试自己接收 SIGINT，确认 SIGINT 没有被 ReloadOnSignal 监听
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	r := NewRegistry()
+	errs := make(chan error, 1)
+	stop := r.ReloadOnSignal(filepath.Join(t.TempDir(), "missing.txt"), func(err error) { errs <- err })
+	defer stop()
+
+	if err := self.Signal(os.Interrupt); err != nil {
+		t.Skipf("signals not supported: %v", err)
+	}
+	select {
+	case <-interrupt:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGINT not delivered")
+	}
+	select {
+	case err := <-errs:
+		t.Fatalf("SIGINT triggered a reload: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := self.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("SIGHUP not supported: %v", err)
+	}
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "missing.txt") {
+			t.Errorf("reload error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGHUP did not trigger a reload")
+	}
+}
@@ -0,0 +1,189 @@
+package errors
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// 文件内容：
+//	1、type RPCCode uint32
+//		17 个标准 RPC 状态码（OK、INVALID_ARGUMENT、NOT_FOUND 等），
+//		数值与 gRPC 的 codes.Code 相同，但不依赖任何 RPC 库。
+//
+//	2、type RPCCoder interface
+//		可选的 Coder 扩展接口，由 Coder 声明自己的 RPC 状态码。
+//		未实现 RPCCoder 的 Coder 使用 HTTP 状态码推导 RPC 状态码。
+//
+//	3、转换函数：RPCCodeFromHTTP()、HTTPStatusFromRPC()
+//
+//	4、RPCStatus() 返回错误链对应的 RPC 状态码
+
+// RPCCode 是标准 RPC 状态码。
+type RPCCode uint32
+
+const (
+	RPCOK                 RPCCode = 0
+	RPCCanceled           RPCCode = 1
+	RPCUnknown            RPCCode = 2
+	RPCInvalidArgument    RPCCode = 3
+	RPCDeadlineExceeded   RPCCode = 4
+	RPCNotFound           RPCCode = 5
+	RPCAlreadyExists      RPCCode = 6
+	RPCPermissionDenied   RPCCode = 7
+	RPCResourceExhausted  RPCCode = 8
+	RPCFailedPrecondition RPCCode = 9
+	RPCAborted            RPCCode = 10
+	RPCOutOfRange         RPCCode = 11
+	RPCUnimplemented      RPCCode = 12
+	RPCInternal           RPCCode = 13
+	RPCUnavailable        RPCCode = 14
+	RPCDataLoss           RPCCode = 15
+	RPCUnauthenticated    RPCCode = 16
+)
+
+var rpcCodeNames = [...]string{
+	RPCOK:                 "OK",
+	RPCCanceled:           "CANCELLED",
+	RPCUnknown:            "UNKNOWN",
+	RPCInvalidArgument:    "INVALID_ARGUMENT",
+	RPCDeadlineExceeded:   "DEADLINE_EXCEEDED",
+	RPCNotFound:           "NOT_FOUND",
+	RPCAlreadyExists:      "ALREADY_EXISTS",
+	RPCPermissionDenied:   "PERMISSION_DENIED",
+	RPCResourceExhausted:  "RESOURCE_EXHAUSTED",
+	RPCFailedPrecondition: "FAILED_PRECONDITION",
+	RPCAborted:            "ABORTED",
+	RPCOutOfRange:         "OUT_OF_RANGE",
+	RPCUnimplemented:      "UNIMPLEMENTED",
+	RPCInternal:           "INTERNAL",
+	RPCUnavailable:        "UNAVAILABLE",
+	RPCDataLoss:           "DATA_LOSS",
+	RPCUnauthenticated:    "UNAUTHENTICATED",
+}
+
+// String 返回 RPC 状态码的标准名称，例如 INVALID_ARGUMENT。
+func (c RPCCode) String() string {
+	if int(c) < len(rpcCodeNames) {
+		return rpcCodeNames[c]
+	}
+	return "CODE(" + strconv.FormatUint(uint64(c), 10) + ")"
+}
+
+// ParseRPCCode 解析 RPC 状态码的标准名称。
+func ParseRPCCode(name string) (RPCCode, bool) {
+	for c, n := range rpcCodeNames {
+		if n == name {
+			return RPCCode(c), true
+		}
+	}
+
+	return RPCUnknown, false
+}
+
+// RPCCoder 是声明了 RPC 状态码的 Coder。
+type RPCCoder interface {
+	Coder
+
+	// RPCStatus 返回该错误码对应的 RPC 状态码
+	RPCStatus() RPCCode
+}
+
+// ===================================================================
+// httpToRPC 是 HTTP 状态码到 RPC 状态码的映射
+var httpToRPC = map[int]RPCCode{
+	http.StatusOK:                           RPCOK,
+	http.StatusBadRequest:                   RPCInvalidArgument,
+	http.StatusUnauthorized:                 RPCUnauthenticated,
+	http.StatusForbidden:                    RPCPermissionDenied,
+	http.StatusNotFound:                     RPCNotFound,
+	http.StatusConflict:                     RPCAborted,
+	http.StatusPreconditionFailed:           RPCFailedPrecondition,
+	http.StatusRequestedRangeNotSatisfiable: RPCOutOfRange,
+	http.StatusTooManyRequests:              RPCResourceExhausted,
+	499:                                     RPCCanceled,
+	http.StatusInternalServerError:          RPCInternal,
+	http.StatusNotImplemented:               RPCUnimplemented,
+	http.StatusServiceUnavailable:           RPCUnavailable,
+	http.StatusGatewayTimeout:               RPCDeadlineExceeded,
+}
+
+// rpcToHTTP 是 RPC 状态码到 HTTP 状态码的映射
+var rpcToHTTP = [...]int{
+	RPCOK:                 http.StatusOK,
+	RPCCanceled:           499,
+	RPCUnknown:            http.StatusInternalServerError,
+	RPCInvalidArgument:    http.StatusBadRequest,
+	RPCDeadlineExceeded:   http.StatusGatewayTimeout,
+	RPCNotFound:           http.StatusNotFound,
+	RPCAlreadyExists:      http.StatusConflict,
+	RPCPermissionDenied:   http.StatusForbidden,
+	RPCResourceExhausted:  http.StatusTooManyRequests,
+	RPCFailedPrecondition: http.StatusBadRequest,
+	RPCAborted:            http.StatusConflict,
+	RPCOutOfRange:         http.StatusBadRequest,
+	RPCUnimplemented:      http.StatusNotImplemented,
+	RPCInternal:           http.StatusInternalServerError,
+	RPCUnavailable:        http.StatusServiceUnavailable,
+	RPCDataLoss:           http.StatusInternalServerError,
+	RPCUnauthenticated:    http.StatusUnauthorized,
+}
+
+// RPCCodeFromHTTP 返回 HTTP 状态码对应的 RPC 状态码。
+// 未列出的 2xx 状态码映射为 OK，其他未列出的 4xx 映射为 FAILED_PRECONDITION，
+// 5xx 映射为 INTERNAL，其余映射为 UNKNOWN。
+func RPCCodeFromHTTP(status int) RPCCode {
+	if c, ok := httpToRPC[status]; ok {
+		return c
+	}
+
+	switch {
+	case status >= 200 && status < 300:
+		return RPCOK
+	case status >= 400 && status < 500:
+		return RPCFailedPrecondition
+	case status >= 500 && status < 600:
+		return RPCInternal
+	default:
+		return RPCUnknown
+	}
+}
+
+// HTTPStatusFromRPC 返回 RPC 状态码对应的 HTTP 状态码，未知的 RPC 状态码映射为 500。
+func HTTPStatusFromRPC(code RPCCode) int {
+	if int(code) < len(rpcToHTTP) {
+		return rpcToHTTP[code]
+	}
+	return http.StatusInternalServerError
+}
+
+// coderRPCStatus 返回 coder 的 RPC 状态码
+func coderRPCStatus(coder Coder) RPCCode {
+	if rc, ok := coder.(RPCCoder); ok {
+		return rc.RPCStatus()
+	}
+
+	return RPCCodeFromHTTP(coder.HTTPStatus())
+}
+
+// RPCStatus 返回 coder 的 RPC 状态码，由 HTTP 状态码推导。
+func (coder defaultCoder) RPCStatus() RPCCode {
+	return RPCCodeFromHTTP(coder.HTTPStatus())
+}
+
+// ===================================================================
+// RPCStatus 使用 r 解析 err 的 RPC 状态码。
+// 与 ParseCoder 相同，使用错误树中第一个 withCode 错误的 Coder（远程错误使用解码的 Coder，见 DecodeResponse），
+// 错误码未注册或者没有 withCode 错误时使用 unknown Coder，因此与 HTTP 状态码一致。
+// err 为 nil 时返回 OK。
+func (r *Registry) RPCStatus(err error) RPCCode {
+	if err == nil {
+		return RPCOK
+	}
+
+	return coderRPCStatus(r.ParseCoder(err))
+}
+
+// RPCStatus 使用渲染 err 的 Registry 返回 err 的 RPC 状态码，err 为 nil 时返回 OK。
+func RPCStatus(err error) RPCCode {
+	return registryOf(err).RPCStatus(err)
+}
@@ -0,0 +1,121 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+type rpcCoder struct {
+	defaultCoder
+	rpc RPCCode
+}
+
+func (coder rpcCoder) RPCStatus() RPCCode {
+	return coder.rpc
+}
+
+func TestRPCCodeFromHTTP(t *testing.T) {
+	tests := []struct {
+		status int
+		want   RPCCode
+	}{
+		{200, RPCOK},
+		{204, RPCOK},
+		{400, RPCInvalidArgument},
+		{401, RPCUnauthenticated},
+		{403, RPCPermissionDenied},
+		{404, RPCNotFound},
+		{409, RPCAborted},
+		{418, RPCFailedPrecondition},
+		{429, RPCResourceExhausted},
+		{499, RPCCanceled},
+		{500, RPCInternal},
+		{501, RPCUnimplemented},
+		{503, RPCUnavailable},
+		{504, RPCDeadlineExceeded},
+		{599, RPCInternal},
+		{302, RPCUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := RPCCodeFromHTTP(tt.status); got != tt.want {
+			t.Errorf("RPCCodeFromHTTP(%d) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestHTTPStatusFromRPC(t *testing.T) {
+	for c := RPCOK; c <= RPCUnauthenticated; c++ {
+		status := HTTPStatusFromRPC(c)
+		if status == 0 {
+			t.Errorf("HTTPStatusFromRPC(%s) = 0", c)
+		}
+
+		// 一一对应的状态码可以往返转换
+		if back := RPCCodeFromHTTP(status); httpToRPC[status] == c && back != c {
+			t.Errorf("RPCCodeFromHTTP(HTTPStatusFromRPC(%s)) = %s", c, back)
+		}
+	}
+
+	if got := HTTPStatusFromRPC(RPCCode(100)); got != 500 {
+		t.Errorf("HTTPStatusFromRPC(100) = %d, want 500", got)
+	}
+}
+
+func TestParseRPCCode(t *testing.T) {
+	for c := RPCOK; c <= RPCUnauthenticated; c++ {
+		if got, ok := ParseRPCCode(c.String()); !ok || got != c {
+			t.Errorf("ParseRPCCode(%q) = %s, %v", c.String(), got, ok)
+		}
+	}
+
+	if _, ok := ParseRPCCode("NOPE"); ok {
+		t.Error("ParseRPCCode(NOPE) succeeded")
+	}
+
+	if got := RPCCode(42).String(); got != "CODE(42)" {
+		t.Errorf("RPCCode(42).String() = %q", got)
+	}
+}
+
+func TestRegistryRPCStatus(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(defaultCoder{C: 120001, HTTP: 404, Ext: "Not found"})
+	r.MustRegister(rpcCoder{defaultCoder{C: 120002, HTTP: 400, Ext: "Stale"}, RPCFailedPrecondition})
+
+	tests := []struct {
+		name string
+		err  error
+		want RPCCode
+	}{
+		{"nil", nil, RPCOK},
+		{"uncoded", New("boom"), RPCInternal},
+		{"registered", r.WithCode(120001, "user"), RPCNotFound},
+		{"rpc coder", r.WithCode(120002, "stale"), RPCFailedPrecondition},
+		{"wrapped by foreign error", fmt.Errorf("get: %w", r.WithCode(120001, "user")), RPCNotFound},
+		// 与 ParseCoder 相同，使用第一个 withCode 错误，未注册的错误码使用 unknown Coder
+		{"unregistered outer code", r.WrapC(r.WithCode(120001, "user"), 999999, "outer"), RPCInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.RPCStatus(tt.err); got != tt.want {
+				t.Errorf("RPCStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRPCStatusAgreesWithHTTPStatus(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(defaultCoder{C: 120001, HTTP: 404, Ext: "Not found"})
+
+	err := r.WrapC(r.WithCode(120001, "user"), 999999, "outer")
+	if status := r.ParseCoder(err).HTTPStatus(); status != 500 {
+		t.Fatalf("ParseCoder().HTTPStatus() = %d, want 500", status)
+	}
+
+	if got, want := r.RPCStatus(err), RPCCodeFromHTTP(500); got != want {
+		t.Errorf("RPCStatus() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 文件内容：
+//	1、type Site struct
+//		错误码注册的位置：注册错误码的调用栈帧，以及通过目录注册时在目录中的位置
+//
+//	2、Registry.Site() 返回错误码注册的位置
+//
+// 错误码（包括别名）注册时会记录调用栈中第一个不属于该包的栈帧，
+// 重复注册时返回的错误（或 panic）同时包含两次注册的位置，
+// Registry.Catalog()、Registry.MarshalJSON() 的输出也会包含注册的位置。
+
+// pkgPrefix 是该包中函数名的前缀
+const pkgPrefix = "github.com/tiandh987/errors."
+
+// Site 是错误码注册的位置。
+type Site struct {
+	// Frame 注册错误码的调用栈帧
+	Frame Frame
+
+	// Source 错误码在目录中的位置，例如 codes.json: line 3，不是通过目录注册时为空
+	Source string
+}
+
+// String 返回注册位置的描述，例如：
+//
+//	/src/user/code.go:12 (github.com/marmotedu/user.init.0)
+//	codes.json: line 3 (loaded at /src/main.go:20 (main.main))
+func (s Site) String() string {
+	loc := "unknown"
+	if s.Frame != 0 {
+		loc = fmt.Sprintf("%s:%d (%s)", s.Frame.file(), s.Frame.line(), s.Frame.name())
+	}
+
+	if s.Source != "" {
+		return s.Source + " (loaded at " + loc + ")"
+	}
+
+	return loc
+}
+
+// callerSite 返回调用栈中第一个不属于该包的栈帧
+func callerSite() Site {
+	st := callers()
+	for _, pc := range *st {
+		if f := Frame(pc); !strings.HasPrefix(f.name(), pkgPrefix) {
+			return Site{Frame: f}
+		}
+	}
+
+	return Site{}
+}
+
+// copySites 复制 m，m 为 nil 时返回空 map
+func copySites(m map[int]Site) map[int]Site {
+	next := make(map[int]Site, len(m))
+	for k, v := range m {
+		next[k] = v
+	}
+
+	return next
+}
+
+// Site 返回错误码（或别名）code 注册的位置，code 未注册时 ok 为 false。
+func (r *Registry) Site(code int) (site Site, ok bool) {
+	site, ok = r.load().sites[code]
+	return
+}
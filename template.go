@@ -0,0 +1,206 @@
+package errors
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 文件内容：
+//	1、type Params map
+//		错误的命名参数，用于填充外部错误文本模板中的占位符
+//
+//	2、type TemplateCoder interface
+//		可选的 Coder 扩展接口，外部错误文本为模板，Coder.String() 为参数缺失时使用的安全文本
+//		NewTemplateCoder() 创建 TemplateCoder
+//
+//	3、带参数的错误：WithCodeParams()、WrapCParams()
+//
+// 模板使用 {name} 作为占位符，例如 "Field '{field}' is invalid"，
+// 格式化（%s、%v 等）、Localize()、NewProblem() 渲染外部错误文本时使用错误的参数填充模板。
+// 多语言消息目录（AddMessages）以及 LocalizedCoder 返回的文本也可以包含占位符。
+// 模板中任何一个占位符缺少参数时，不使用该模板，回退到下一个候选文本，
+// 最后回退到 Coder.String()。Coder.String() 中的占位符同样会被填充，
+// 缺少参数的占位符会被删除，因此不会输出未填充的占位符，也不会因为参数引发 panic。
+
+// Params 是错误的命名参数。
+type Params map[string]interface{}
+
+// TemplateCoder 是外部错误文本为模板的 Coder。
+type TemplateCoder interface {
+	Coder
+
+	// Template 返回外部错误文本模板，为空表示没有模板。
+	// 模板中的占位符缺少参数时使用 Coder.String()，其中缺少参数的占位符会被删除。
+	Template() string
+}
+
+// placeholder 匹配模板中的占位符，例如 {field}
+var placeholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// templateCoder 是 NewTemplateCoder 返回的 Coder
+type templateCoder struct {
+	defaultCoder
+	tmpl string
+}
+
+func (coder templateCoder) Template() string {
+	return coder.tmpl
+}
+
+// NewTemplateCoder 创建外部错误文本模板为 tmpl 的 Coder，fallback 为参数缺失时使用的外部错误文本。
+func NewTemplateCoder(code, httpStatus int, tmpl, fallback, ref string) Coder {
+	return templateCoder{
+		defaultCoder: defaultCoder{
+			C:    code,
+			HTTP: httpStatus,
+			Ext:  fallback,
+			Ref:  ref,
+		},
+		tmpl: tmpl,
+	}
+}
+
+// expand 使用 params 填充 tmpl 中的占位符，
+// 有占位符缺少参数时 ok 为 false，返回的文本保留未填充的占位符。
+func expand(tmpl string, params Params) (msg string, ok bool) {
+	if !strings.Contains(tmpl, "{") {
+		return tmpl, true
+	}
+
+	ok = true
+	msg = placeholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		v, found := params[m[1:len(m)-1]]
+		if !found {
+			ok = false
+			return m
+		}
+
+		// fmt 会恢复参数的 String()、Error() 方法引发的 panic
+		return fmt.Sprint(v)
+	})
+
+	return msg, ok
+}
+
+// templateOf 返回 coder 的外部错误文本模板，coder 不是 TemplateCoder 时返回空
+func templateOf(coder Coder) string {
+	if tc, ok := coder.(TemplateCoder); ok {
+		return tc.Template()
+	}
+
+	return ""
+}
+
+// renderMessage 使用 params 渲染 coder 的外部错误文本：
+// 模板的参数齐全时使用模板，否则使用 Coder.String()，
+// 其中的占位符同样会被填充，缺少参数的占位符会被删除。
+func renderMessage(coder Coder, params Params) string {
+	if tmpl := templateOf(coder); tmpl != "" {
+		if msg, ok := expand(tmpl, params); ok {
+			return msg
+		}
+	}
+
+	msg, ok := expand(coder.String(), params)
+	if !ok {
+		msg = stripPlaceholders(msg)
+	}
+
+	return msg
+}
+
+// stripPlaceholders 删除 msg 中的占位符，以及删除后多余的空格，
+// 例如 "User {name} not found" => "User not found"
+func stripPlaceholders(msg string) string {
+	msg = placeholder.ReplaceAllString(msg, "")
+	for strings.Contains(msg, "  ") {
+		msg = strings.ReplaceAll(msg, "  ", " ")
+	}
+
+	return strings.TrimSpace(msg)
+}
+
+// copyParams 复制 params，params 为空时返回 nil
+func copyParams(params Params) Params {
+	if len(params) == 0 {
+		return nil
+	}
+
+	next := make(Params, len(params))
+	for k, v := range params {
+		next[k] = v
+	}
+
+	return next
+}
+
+// paramsOf 返回 err 的错误树中第一个带错误码的错误的参数，没有带错误码的错误时返回 nil
+func paramsOf(err error) Params {
+	if v, ok := firstCoded(err); ok {
+		return v.params
+	}
+
+	return nil
+}
+
+// ===================================================================
+// WithCodeParams 与 WithCode 相同，params 用于填充外部错误文本模板。
+func WithCodeParams(code int, params Params, format string, args ...interface{}) error {
+	defaultRegistry.created(code)
+
+	return &withCode{
+		err:    fmt.Errorf(format, args...),
+		code:   code,
+		params: copyParams(params),
+		stack:  callers(),
+	}
+}
+
+// WrapCParams 与 WrapC 相同，params 用于填充外部错误文本模板。
+// 如果 err 为 nil，WrapCParams 返回 nil。
+func WrapCParams(err error, code int, params Params, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	defaultRegistry.created(code)
+
+	return &withCode{
+		err:    fmt.Errorf(format, args...),
+		code:   code,
+		cause:  err,
+		params: copyParams(params),
+		stack:  callers(),
+	}
+}
+
+// WithCodeParams 与 Registry.WithCode 相同，params 用于填充外部错误文本模板。
+func (r *Registry) WithCodeParams(code int, params Params, format string, args ...interface{}) error {
+	r.created(code)
+
+	return &withCode{
+		err:      fmt.Errorf(format, args...),
+		code:     code,
+		registry: r,
+		params:   copyParams(params),
+		stack:    callers(),
+	}
+}
+
+// WrapCParams 与 Registry.WrapC 相同，params 用于填充外部错误文本模板。
+// 如果 err 为 nil，WrapCParams 返回 nil。
+func (r *Registry) WrapCParams(err error, code int, params Params, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	r.created(code)
+
+	return &withCode{
+		err:      fmt.Errorf(format, args...),
+		code:     code,
+		cause:    err,
+		registry: r,
+		params:   copyParams(params),
+		stack:    callers(),
+	}
+}
@@ -0,0 +1,124 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newTemplateTestRegistry() *Registry {
+	r := NewRegistry()
+	r.MustRegister(
+		NewTemplateCoder(220001, 400, "Field '{field}' is invalid", "Invalid field", ""),
+		NewCoder(220002, 429, "Quota of {plan} plan exceeded", ""),
+		NewTemplateCoder(220003, 404, "User {name} not found", "User {name} not found", ""),
+	)
+	r.AddMessages("zh", map[int]string{220001: "字段 {field} 无效"})
+
+	return r
+}
+
+func TestTemplateCoder(t *testing.T) {
+	coder := NewTemplateCoder(220001, 400, "Field '{field}' is invalid", "Invalid field", "https://example.com/220001")
+
+	tc, ok := coder.(TemplateCoder)
+	if !ok {
+		t.Fatalf("NewTemplateCoder() = %T, want a TemplateCoder", coder)
+	}
+	if tc.Template() != "Field '{field}' is invalid" || coder.String() != "Invalid field" {
+		t.Errorf("Template() = %q, String() = %q", tc.Template(), coder.String())
+	}
+	if coder.Code() != 220001 || coder.HTTPStatus() != 400 || coder.Reference() != "https://example.com/220001" {
+		t.Errorf("coder = %d %d %q", coder.Code(), coder.HTTPStatus(), coder.Reference())
+	}
+}
+
+func TestRenderParams(t *testing.T) {
+	r := newTemplateTestRegistry()
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"template", r.WithCodeParams(220001, Params{"field": "email"}, "invalid email"), "Field 'email' is invalid"},
+		{"template missing params", r.WithCodeParams(220001, nil, "invalid email"), "Invalid field"},
+		{"template extra params", r.WithCodeParams(220001, Params{"field": 1, "other": 2}, "invalid"), "Field '1' is invalid"},
+		{"string placeholders", r.WithCodeParams(220002, Params{"plan": "pro"}, "quota"), "Quota of pro plan exceeded"},
+		{"string placeholders missing", r.WithCodeParams(220002, nil, "quota"), "Quota of plan exceeded"},
+		{"fallback placeholders missing", r.WithCodeParams(220003, Params{"id": 1}, "user"), "User not found"},
+		{"wrapped", r.WrapCParams(New("no rows"), 220003, Params{"name": "alice"}, "get user"), "User alice not found"},
+		{"outer wrap", Wrap(r.WithCodeParams(220001, Params{"field": "name"}, "invalid"), "create user"), "Field 'name' is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprintf("%s", tt.err); got != tt.want {
+				t.Errorf("%%s = %q, want %q", got, tt.want)
+			}
+			if got := r.Localize(tt.err, "en"); got != tt.want {
+				t.Errorf("Localize(en) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderParamsLocalized(t *testing.T) {
+	r := newTemplateTestRegistry()
+
+	if got := r.Localize(r.WithCodeParams(220001, Params{"field": "email"}, "invalid"), "zh-CN"); got != "字段 email 无效" {
+		t.Errorf("Localize(zh-CN) = %q", got)
+	}
+
+	// 翻译缺少参数时回退到默认语言
+	if got := r.Localize(r.WithCodeParams(220001, nil, "invalid"), "zh-CN"); got != "Invalid field" {
+		t.Errorf("Localize(zh-CN) without params = %q", got)
+	}
+}
+
+func TestWithCodeParamsCopiesParams(t *testing.T) {
+	r := newTemplateTestRegistry()
+
+	params := Params{"field": "email"}
+	err := r.WithCodeParams(220001, params, "invalid")
+	params["field"] = "name"
+
+	if got := fmt.Sprintf("%s", err); got != "Field 'email' is invalid" {
+		t.Errorf("%%s = %q, params not copied", got)
+	}
+	if got := paramsOf(err)["field"]; got != "email" {
+		t.Errorf("paramsOf() = %v", got)
+	}
+}
+
+func TestWrapCParams(t *testing.T) {
+	r := newTemplateTestRegistry()
+
+	if err := r.WrapCParams(nil, 220001, Params{"field": "email"}, "invalid"); err != nil {
+		t.Errorf("WrapCParams(nil) = %v", err)
+	}
+	if err := WrapCParams(nil, 220001, Params{"field": "email"}, "invalid"); err != nil {
+		t.Errorf("package WrapCParams(nil) = %v", err)
+	}
+
+	cause := New("no rows")
+	err := r.WrapCParams(cause, 220003, Params{"name": "alice"}, "get user %s", "alice")
+	if !Is(err, cause) || !r.IsCode(err, 220003) {
+		t.Errorf("WrapCParams() = %v, does not wrap the cause with the code", err)
+	}
+	if got := InternalMessage(err); got != "get user alice" {
+		t.Errorf("InternalMessage() = %q", got)
+	}
+}
+
+func TestPackageWithCodeParams(t *testing.T) {
+	err := WithCodeParams(220001, Params{"field": "email"}, "invalid %s", "email")
+	if got := InternalMessage(err); got != "invalid email" {
+		t.Errorf("InternalMessage() = %q", got)
+	}
+	if got := paramsOf(err)["field"]; got != "email" {
+		t.Errorf("paramsOf() = %v", got)
+	}
+	if got := paramsOf(WrapCParams(New("boom"), 220001, Params{"field": "name"}, "invalid"))["field"]; got != "name" {
+		t.Errorf("paramsOf(WrapCParams) = %v", got)
+	}
+}
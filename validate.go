@@ -0,0 +1,207 @@
+package errors
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 文件内容：
+//	1、type Validator func
+//		注册错误码时对 Coder 进行的校验
+//
+//	2、预定义的校验：
+//		AllowedHTTPStatus()      HTTP 状态码只能是给定的值
+//		NonEmptyMessage()        外部错误文本不能为空
+//		CapitalizedMessage()     外部错误文本以大写字母开头
+//		NoTrailingPeriod()       外部错误文本不能以句号结尾
+//		MaxMessageLength()       外部错误文本的最大长度
+//		ReferencePrefix()        reference 文档必须以给定的前缀开头
+//		CodeRange()              错误码必须在给定的范围内
+//
+//	3、Registry.AddValidator()、Registry.Validate()
+//
+// Registry 的所有校验在每次注册时运行：Register、MustRegister、TryRegister、
+// RegisterCatalog、LoadCatalog、Namespace.Register，以及 Reload 之后的 Coder。
+// 校验失败时 TryRegister、RegisterCatalog 等返回错误，Register、MustRegister 引发 panic，
+// 返回的错误包含所有失败的校验。外部错误文本的校验同时检查 Coder.String() 以及 TemplateCoder 的模板。
+//
+// 使用：
+//	包级别的错误码通常在 init 中注册，因此校验需要在注册之前添加，
+//	例如在错误码所在包最先初始化的变量中添加，或者在 main 中调用 Validate() 检查已经注册的错误码：
+//		errors.AddValidator(
+//			errors.AllowedHTTPStatus(200, 400, 401, 403, 404, 500),
+//			errors.NonEmptyMessage(), errors.CapitalizedMessage(), errors.NoTrailingPeriod(),
+//			errors.MaxMessageLength(80),
+//		)
+
+// Validator 校验注册的 Coder，不合法时返回错误。
+type Validator func(coder Coder) error
+
+// messages 返回 coder 的外部错误文本以及模板
+func messages(coder Coder) []string {
+	msgs := []string{coder.String()}
+	if tmpl := templateOf(coder); tmpl != "" {
+		msgs = append(msgs, tmpl)
+	}
+
+	return msgs
+}
+
+// messageRule 使用 check 校验 coder 的外部错误文本以及模板，check 返回不合法的原因
+func messageRule(check func(msg string) string) Validator {
+	return func(coder Coder) error {
+		for _, msg := range messages(coder) {
+			if reason := check(msg); reason != "" {
+				return Errorf("message %q %s", msg, reason)
+			}
+		}
+
+		return nil
+	}
+}
+
+// AllowedHTTPStatus 返回校验 HTTP 状态码只能是 statuses 之一的 Validator。
+func AllowedHTTPStatus(statuses ...int) Validator {
+	allowed := make(map[int]bool, len(statuses))
+	for _, status := range statuses {
+		allowed[status] = true
+	}
+
+	return func(coder Coder) error {
+		if !allowed[coder.HTTPStatus()] {
+			return Errorf("http status %d is not allowed", coder.HTTPStatus())
+		}
+
+		return nil
+	}
+}
+
+// NonEmptyMessage 返回校验外部错误文本不为空（不全是空白字符）的 Validator。
+func NonEmptyMessage() Validator {
+	return func(coder Coder) error {
+		if strings.TrimSpace(coder.String()) == "" {
+			return Errorf("empty message")
+		}
+
+		return nil
+	}
+}
+
+// CapitalizedMessage 返回校验外部错误文本不以小写字母开头的 Validator，
+// 以数字、没有大小写的文字（例如中文）开头的文本不会被检查。
+func CapitalizedMessage() Validator {
+	return messageRule(func(msg string) string {
+		r, _ := utf8.DecodeRuneInString(msg)
+		if unicode.IsLower(r) {
+			return "is not capitalized"
+		}
+
+		return ""
+	})
+}
+
+// NoTrailingPeriod 返回校验外部错误文本不以句号结尾的 Validator。
+func NoTrailingPeriod() Validator {
+	return messageRule(func(msg string) string {
+		if strings.HasSuffix(msg, ".") || strings.HasSuffix(msg, "。") {
+			return "ends with a period"
+		}
+
+		return ""
+	})
+}
+
+// MaxMessageLength 返回校验外部错误文本最多 n 个字符（不是字节）的 Validator。
+func MaxMessageLength(n int) Validator {
+	return messageRule(func(msg string) string {
+		if utf8.RuneCountInString(msg) > n {
+			return "is longer than " + strconv.Itoa(n) + " characters"
+		}
+
+		return ""
+	})
+}
+
+// ReferencePrefix 返回校验 reference 文档以 prefixes 之一开头的 Validator，
+// 没有 reference 文档的 Coder 不会被检查。
+func ReferencePrefix(prefixes ...string) Validator {
+	return func(coder Coder) error {
+		ref := coder.Reference()
+		if ref == "" {
+			return nil
+		}
+
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(ref, prefix) {
+				return nil
+			}
+		}
+
+		return Errorf("reference %q does not start with %s", ref, strings.Join(prefixes, " or "))
+	}
+}
+
+// CodeRange 返回校验错误码在 [min, max] 范围内的 Validator。
+func CodeRange(min, max int) Validator {
+	return func(coder Coder) error {
+		if coder.Code() < min || coder.Code() > max {
+			return Errorf("code is outside range [%d, %d]", min, max)
+		}
+
+		return nil
+	}
+}
+
+// validate 使用 s 中的所有 Validator 校验 coder，返回的错误包含所有失败的校验
+func (s *registryState) validate(coder Coder) error {
+	var errs []error
+	for _, v := range s.validators {
+		if err := v(coder); err != nil {
+			errs = append(errs, WithMessagef(err, "validate code %d: %v", coder.Code(), err))
+		}
+	}
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+
+	return NewAggregate(errs)
+}
+
+// ===================================================================
+// AddValidator 为 r 添加注册时运行的校验，已经注册的错误码不会被校验，可以使用 Validate() 检查。
+// r 已经冻结时将会引发 panic。
+func (r *Registry) AddValidator(validators ...Validator) {
+	r.mustUpdate(func(next *registryState) error {
+		next.validators = append(next.validators[:len(next.validators):len(next.validators)], validators...)
+		return nil
+	})
+}
+
+// Validate 使用 r 的所有校验检查已经注册的错误码（不包括 unknown Coder），
+// 返回的错误包含所有失败的校验，全部通过时返回 nil。
+func (r *Registry) Validate() error {
+	state := r.load()
+
+	var errs []error
+	for _, coder := range r.coders() {
+		if err := state.validate(coder); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return NewAggregate(errs)
+}
+
+// ===================================================================
+// AddValidator 为默认 Registry 添加注册时运行的校验。
+func AddValidator(validators ...Validator) {
+	defaultRegistry.AddValidator(validators...)
+}
+
+// Validate 使用默认 Registry 的所有校验检查已经注册的错误码。
+func Validate() error {
+	return defaultRegistry.Validate()
+}
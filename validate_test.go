@@ -0,0 +1,115 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator Validator
+		coder     Coder
+		wantErr   string
+	}{
+		{"allowed status", AllowedHTTPStatus(400, 404), NewCoder(240001, 404, "User not found", ""), ""},
+		{"status not allowed", AllowedHTTPStatus(400, 404), NewCoder(240001, 418, "Teapot", ""), "http status 418 is not allowed"},
+		{"non empty", NonEmptyMessage(), NewCoder(240001, 400, "Invalid", ""), ""},
+		{"empty message", NonEmptyMessage(), NewCoder(240001, 400, " ", ""), "empty message"},
+		{"capitalized", CapitalizedMessage(), NewCoder(240001, 400, "用户不存在", ""), ""},
+		{"not capitalized", CapitalizedMessage(), NewCoder(240001, 400, "invalid", ""), "is not capitalized"},
+		{"template not capitalized", CapitalizedMessage(), NewTemplateCoder(240001, 400, "field {f} is invalid", "Invalid field", ""), "is not capitalized"},
+		{"trailing period", NoTrailingPeriod(), NewCoder(240001, 400, "Invalid.", ""), "ends with a period"},
+		{"trailing chinese period", NoTrailingPeriod(), NewCoder(240001, 400, "参数无效。", ""), "ends with a period"},
+		{"max length", MaxMessageLength(5), NewCoder(240001, 400, "参数无效啊", ""), ""},
+		{"too long", MaxMessageLength(5), NewCoder(240001, 400, "Invalid", ""), "longer than 5 characters"},
+		{"no reference", ReferencePrefix("https://example.com/"), NewCoder(240001, 400, "Invalid", ""), ""},
+		{"reference prefix", ReferencePrefix("https://example.com/"), NewCoder(240001, 400, "Invalid", "https://other.com/x"), "does not start with"},
+		{"code range", CodeRange(240000, 240999), NewCoder(241000, 400, "Invalid", ""), "outside range [240000, 240999]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validator(tt.coder)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validator() = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validator() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatorRejectsRegistration(t *testing.T) {
+	r := NewRegistry()
+	r.AddValidator(AllowedHTTPStatus(400, 404), NoTrailingPeriod())
+
+	// TryRegister 返回包含所有失败校验的错误，不注册同一批次中的任何 Coder
+	err := r.TryRegister(
+		NewCoder(240101, 404, "User not found", ""),
+		NewCoder(240102, 500, "Internal error.", ""),
+	)
+	if err == nil {
+		t.Fatal("TryRegister() = nil, want a validation error")
+	}
+	for _, want := range []string{"validate code 240102", "http status 500 is not allowed", "ends with a period"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("TryRegister() = %v, missing %q", err, want)
+		}
+	}
+	if len(r.Codes()) != 0 {
+		t.Errorf("Codes() = %v, want nothing registered", r.Codes())
+	}
+
+	if err := r.TryRegister(NewCoder(240101, 404, "User not found", "")); err != nil {
+		t.Errorf("TryRegister() of a valid coder = %v", err)
+	}
+
+	// MustRegister、Register 引发 panic
+	for name, register := range map[string]func(){
+		"MustRegister": func() { r.MustRegister(NewCoder(240103, 418, "Teapot", "")) },
+		"Register":     func() { r.Register(NewCoder(240103, 418, "Teapot", "")) },
+	} {
+		func() {
+			defer func() {
+				if v := recover(); v == nil || !strings.Contains(fmt.Sprint(v), "http status 418") {
+					t.Errorf("%s panic = %v, want the validation error", name, v)
+				}
+			}()
+			register()
+		}()
+	}
+	if _, ok := r.Lookup(240103); ok {
+		t.Error("invalid coder registered")
+	}
+}
+
+func TestValidateRegisteredCodes(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewCoder(240201, 404, "User not found", ""), NewCoder(240202, 400, "invalid name", ""))
+
+	// 添加校验之前注册的错误码不会被校验，使用 Validate 检查
+	r.AddValidator(CapitalizedMessage())
+	err := r.Validate()
+	if err == nil || !strings.Contains(err.Error(), "validate code 240202") || strings.Contains(err.Error(), "240201") {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestAddValidatorAfterFreeze(t *testing.T) {
+	r := NewRegistry()
+	r.Freeze()
+
+	defer func() {
+		if v := recover(); v == nil || !strings.Contains(fmt.Sprint(v), "frozen") {
+			t.Errorf("AddValidator() after Freeze panic = %v, want the frozen error", v)
+		}
+	}()
+	r.AddValidator(NonEmptyMessage())
+}